@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// AuthFileFlag is the --authfile flag name shared by the build
+// subcommands, overriding the default ~/.docker/config.json /
+// $XDG_RUNTIME_DIR/containers/auth.json discovery order.
+const AuthFileFlag = "authfile"
+
+// AddAuthFileFlag registers --authfile on cmd's flag set.
+func AddAuthFileFlag(flags *pflag.FlagSet, authFile *string) {
+	flags.StringVar(authFile, AuthFileFlag, "",
+		"Path to a Docker/Podman auth.json file to use instead of the default discovery locations")
+}
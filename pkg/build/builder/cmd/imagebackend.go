@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/openshift/builder/pkg/build/builder/imagebackend"
+)
+
+// ImageBackendFlag is the --image-backend flag name shared by the build
+// subcommands, letting operators choose between talking to a Docker
+// daemon and running daemonlessly against local containers/storage.
+const ImageBackendFlag = "image-backend"
+
+// AddImageBackendFlag registers --image-backend on cmd's flag set,
+// defaulting to the historical Docker-daemon behavior.
+func AddImageBackendFlag(flags *pflag.FlagSet, backend *string) {
+	flags.StringVar(backend, ImageBackendFlag, string(imagebackend.Docker),
+		"Image backend to use for inspect/pull operations: docker or podman. "+
+			"The Docker build and commit steps always require a Docker daemon, regardless of this setting.")
+}
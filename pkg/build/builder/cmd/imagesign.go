@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/openshift/builder/pkg/build/builder/imagesign"
+)
+
+// AddImageSignFlags registers the --signature-policy, --sign-by,
+// --sign-passphrase-file and --sigstore-storage-url flags on cmd's flag
+// set, populating cfg. These are a convenience for constructing an
+// imagesign.Config from the CLI; callers embedding builder as a library
+// should build Config directly and pass it to S2IBuilder.SetSigningConfig
+// instead.
+func AddImageSignFlags(flags *pflag.FlagSet, cfg *imagesign.Config) {
+	flags.StringVar(&cfg.SignaturePolicyPath, "signature-policy", "",
+		"Path to a containers policy.json used to verify base images before assemble")
+	flags.StringVar(&cfg.SignBy, "sign-by", "",
+		"GPG key ID to sign the built image with on commit")
+	flags.StringVar(&cfg.SignPassphraseFile, "sign-passphrase-file", "",
+		"Path to a file containing the passphrase for --sign-by's private key")
+	flags.StringVar(&cfg.SigstoreStorageURL, "sigstore-storage-url", "",
+		"Local directory (or file:// URL) to store detached signatures produced by --sign-by in")
+}
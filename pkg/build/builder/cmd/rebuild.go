@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildapiv1 "github.com/openshift/api/build/v1"
+	buildclientv1 "github.com/openshift/client-go/build/clientset/versioned/typed/build/v1"
+	s2iapi "github.com/openshift/source-to-image/pkg/api"
+
+	"github.com/openshift/builder/pkg/build/builder"
+	"github.com/openshift/builder/pkg/build/builder/imagebackend"
+	"github.com/openshift/builder/pkg/build/builder/imagesign"
+)
+
+// RebuildRecommendedName is the recommended name for the rebuild command.
+const RebuildRecommendedName = "rebuild"
+
+// RebuildOptions holds the configuration needed to rebuild a previously
+// produced S2I image without requiring the original BuildConfig fields.
+type RebuildOptions struct {
+	DockerClient builder.DockerClient
+	DockerSocket string
+	Client       buildclientv1.BuildInterface
+	Build        *buildapiv1.Build
+	CGLimits     *s2iapi.CGroupLimits
+
+	Image  string
+	NewTag string
+
+	// ImageBackend selects docker or podman for inspect/pull operations
+	// (--image-backend); the Docker build/commit steps always require a
+	// Docker daemon regardless of this setting.
+	ImageBackend string
+	// AuthFile overrides the default Docker/Podman credential-file
+	// discovery order (--authfile).
+	AuthFile string
+	// TLSVerify overrides registries.conf's insecure setting
+	// (--tls-verify); only honored when explicitly set on the command
+	// line, see tlsVerifyChanged.
+	TLSVerify bool
+	// SigningConfig holds the --signature-policy/--sign-by/
+	// --sign-passphrase-file/--sigstore-storage-url settings applied to
+	// base-image verification and the final commit.
+	SigningConfig imagesign.Config
+
+	tlsVerifyChanged bool
+}
+
+// NewCmdRebuild creates the "rebuild" subcommand, which re-runs an S2I
+// build starting from an image produced by a previous S2I build rather
+// than from the BuildConfig on disk. This supports "rebuild latest"
+// flows (e.g. CVE-driven rebase runs) where only the base builder image
+// changed and the original build inputs may no longer be available.
+func NewCmdRebuild(name string, o *RebuildOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s IMAGE [NEW-TAG]", name),
+		Short: "Rebuild an image previously produced by an S2I build",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return cmd.Help()
+			}
+			o.Image = args[0]
+			if len(args) > 1 {
+				o.NewTag = args[1]
+			}
+			o.tlsVerifyChanged = cmd.Flags().Changed(TLSVerifyFlag)
+			return o.Run()
+		},
+	}
+	AddImageBackendFlag(cmd.Flags(), &o.ImageBackend)
+	AddAuthFileFlag(cmd.Flags(), &o.AuthFile)
+	AddTLSVerifyFlag(cmd.Flags(), &o.TLSVerify)
+	AddImageSignFlags(cmd.Flags(), &o.SigningConfig)
+	return cmd
+}
+
+// Run reconstructs the S2I build configuration from the given image's
+// labels and executes the build again.
+func (o *RebuildOptions) Run() error {
+	b := builder.NewS2IBuilder(o.DockerClient, o.DockerSocket, o.Client, o.Build, o.CGLimits)
+	var tlsVerifyOverride *bool
+	if o.tlsVerifyChanged {
+		tlsVerifyOverride = &o.TLSVerify
+	}
+	if err := b.SetImageBackend(imagebackend.Name(o.ImageBackend), o.AuthFile, tlsVerifyOverride); err != nil {
+		return err
+	}
+	b.SetSigningConfig(o.SigningConfig)
+	if len(o.NewTag) != 0 {
+		if o.Build.Spec.Output.To == nil {
+			o.Build.Spec.Output.To = &corev1.ObjectReference{}
+		}
+		o.Build.Spec.Output.To.Name = o.NewTag
+	}
+	return b.Rebuild(o.Image)
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// TLSVerifyFlag is the --tls-verify flag name shared by the build
+// subcommands, overriding whatever registries.conf says for every
+// registry contacted during the build.
+const TLSVerifyFlag = "tls-verify"
+
+// AddTLSVerifyFlag registers --tls-verify on cmd's flag set, defaulting
+// to verified; callers check flags.Changed(TLSVerifyFlag) to tell
+// "explicitly set" apart from "defer to registries.conf".
+func AddTLSVerifyFlag(flags *pflag.FlagSet, tlsVerify *bool) {
+	flags.BoolVar(tlsVerify, TLSVerifyFlag, true,
+		"Require HTTPS and verify certificates when contacting registries (overrides registries.conf insecure entries)")
+}
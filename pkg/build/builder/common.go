@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	dockerclient "github.com/fsouza/go-dockerclient"
+
+	buildapiv1 "github.com/openshift/api/build/v1"
+)
+
+// PostCommitHookFailedError is returned by runPostCommitHook when the hook
+// container exits with a non-zero status. Callers translate it into
+// buildapiv1.StatusReasonPostCommitHookFailed on the Build status.
+type PostCommitHookFailedError struct {
+	ExitCode int
+}
+
+func (e *PostCommitHookFailedError) Error() string {
+	return fmt.Sprintf("the post-commit hook exited with status %d", e.ExitCode)
+}
+
+// runPostCommitHook executes the build's PostCommit hook (Command, Args or
+// Script) in an ephemeral container started from imageID, streaming its
+// output to stdout/stderr. It is shared by S2IBuilder and DockerBuilder so
+// post-commit hooks behave identically across strategies instead of being
+// injected into the built image itself.
+func runPostCommitHook(dockerClient DockerClient, build *buildapiv1.Build, imageID string) error {
+	postCommit := build.Spec.PostCommit
+	if len(postCommit.Command) == 0 && len(postCommit.Args) == 0 && len(postCommit.Script) == 0 {
+		return nil
+	}
+
+	var cmd, entrypoint []string
+	switch {
+	case len(postCommit.Script) != 0:
+		// Run the script with /bin/sh -c, passing Args through as
+		// positional parameters ($1, $2, ...; $0 is "container").
+		entrypoint = []string{"/bin/sh", "-c"}
+		cmd = append([]string{postCommit.Script, "container"}, postCommit.Args...)
+	case len(postCommit.Command) != 0:
+		entrypoint = postCommit.Command
+		cmd = postCommit.Args
+	default:
+		cmd = postCommit.Args
+	}
+
+	name := postCommitContainerName(build.Name, build.Namespace)
+
+	container, err := dockerClient.CreateContainer(dockerclient.CreateContainerOptions{
+		Name: name,
+		Config: &dockerclient.Config{
+			Image:      imageID,
+			Entrypoint: entrypoint,
+			Cmd:        cmd,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create post commit hook container: %v", err)
+	}
+	defer dockerClient.RemoveContainer(dockerclient.RemoveContainerOptions{ID: container.ID, Force: true})
+
+	if err := dockerClient.StartContainer(container.ID, nil); err != nil {
+		return fmt.Errorf("failed to start post commit hook container: %v", err)
+	}
+
+	statusChan := make(chan error, 1)
+	go func() {
+		_, err := dockerClient.AttachToContainer(dockerclient.AttachToContainerOptions{
+			Container:    container.ID,
+			OutputStream: os.Stdout,
+			ErrorStream:  os.Stderr,
+			Stdout:       true,
+			Stderr:       true,
+			Stream:       true,
+			Logs:         true,
+		})
+		statusChan <- err
+	}()
+
+	exitCode, err := dockerClient.WaitContainer(container.ID)
+	<-statusChan
+	if err != nil {
+		return fmt.Errorf("error waiting for post commit hook container: %v", err)
+	}
+	if exitCode != 0 {
+		return &PostCommitHookFailedError{ExitCode: int(exitCode)}
+	}
+	return nil
+}
+
+// postCommitContainerName produces a unique, recognizable name for the
+// ephemeral container used to run a build's post-commit hook.
+func postCommitContainerName(name, namespace string) string {
+	return strings.Join([]string{"openshift_s2i-build", name, namespace, "post-commit", fmt.Sprintf("%08x", rand.Uint32())}, "_")
+}
@@ -0,0 +1,112 @@
+// Package confidential provides pluggable backends that turn a freshly
+// built image into a confidential-computing image (SEV-SNP, TDX, SGX) and
+// register it with an attestation service. It replaces the hardcoded
+// "-cw" tag-matching path that used to live in S2IBuilder.Build.
+package confidential
+
+import (
+	"fmt"
+	"os"
+)
+
+// AnnotationConfidentialImage is the build annotation that opts a build
+// into confidential-image generation. Its value selects the backend
+// (e.g. "sev-snp", "tdx", "sgx"); detection no longer relies on a
+// substring match against the output tag.
+const AnnotationConfidentialImage = "build.openshift.io/confidential-image"
+
+// Config holds the settings needed to generate and register a
+// confidential image, sourced from the build environment (see
+// ConfigFromEnv) rather than being hardcoded. Loading these settings
+// from a mounted configuration file is not implemented.
+type Config struct {
+	// Backend selects which ConfidentialBuilder implementation to use,
+	// e.g. "sev-snp", "tdx", "sgx".
+	Backend string
+	// AttestationServerURL is the base URL of the registration and
+	// attestation service.
+	AttestationServerURL string
+	// AttestationServerAuth is an optional bearer token or other
+	// credential used when talking to the attestation server.
+	AttestationServerAuth string
+	// KernelCmdlineTemplate is the kernel command-line used to boot the
+	// confidential image, with `{{.Password}}`, `{{.WorkDir}}` and
+	// `{{.Env}}` placeholders substituted by the backend.
+	KernelCmdlineTemplate string
+}
+
+// ConfigFromEnv builds a Config from environment variables, falling back
+// to the historical defaults where no override is present.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Backend:               os.Getenv("BUILD_CONFIDENTIAL_BACKEND"),
+		AttestationServerURL:  os.Getenv("BUILD_ATTESTATION_SERVER_URL"),
+		AttestationServerAuth: os.Getenv("BUILD_ATTESTATION_SERVER_AUTH"),
+		KernelCmdlineTemplate: os.Getenv("BUILD_CONFIDENTIAL_KERNEL_CMDLINE"),
+	}
+	if len(cfg.AttestationServerURL) == 0 {
+		cfg.AttestationServerURL = "http://registration-attestation-server.attestation:8080"
+	}
+	if len(cfg.KernelCmdlineTemplate) == 0 {
+		cfg.KernelCmdlineTemplate = defaultKernelCmdlineTemplate
+	}
+	return cfg
+}
+
+const defaultKernelCmdlineTemplate = `KRUN_CFG=2:512 reboot=k panic=-1 panic_print=0 pci=off nomodules console=hvc0 rw no-kvmapf init=/bin/sh virtio_mmio.device=4K@0xd0000000:5 virtio_mmio.device=4K@0xd0001000:6 virtio_mmio.device=4K@0xd0002000:7 virtio_mmio.device=4K@0xd0003000:8 swiotlb=65536 KRUN_PASS={{.Password}} KRUN_INIT=/usr/libexec/s2i/run KRUN_WORKDIR={{.WorkDir}} {{.Env}}`
+
+// Result describes the image produced by a ConfidentialBuilder, ready to
+// be registered with the attestation server.
+type Result struct {
+	// ImageID is the name/tag of the generated confidential image.
+	ImageID string
+	// KernelCmdline is the fully rendered kernel command line used to
+	// boot the confidential image.
+	KernelCmdline string
+}
+
+// ConfidentialBuilder produces a confidential-computing variant of a
+// freshly-built image and registers it with an attestation service.
+// Implementations are selected by Config.Backend.
+type ConfidentialBuilder interface {
+	// Generate converts buildTag (the image produced by the normal
+	// docker build step) into a confidential image and returns its
+	// identity and boot parameters. Any backend-specific inspection of
+	// buildTag (e.g. extracting its working directory or environment)
+	// is the implementation's responsibility, so unsupported backends
+	// fail without paying for it.
+	Generate(buildTag string) (*Result, error)
+	// Register submits the generated image's digest to the attestation
+	// server so it can be verified at launch time.
+	Register(digest string, result *Result) error
+}
+
+// NewConfidentialBuilder returns the ConfidentialBuilder implementation
+// selected by cfg.Backend.
+func NewConfidentialBuilder(cfg Config) (ConfidentialBuilder, error) {
+	switch cfg.Backend {
+	case "", "sev-snp":
+		return &sevSNPBuilder{cfg: cfg}, nil
+	case "tdx":
+		return &unsupportedBuilder{backend: "tdx"}, nil
+	case "sgx":
+		return &unsupportedBuilder{backend: "sgx"}, nil
+	default:
+		return nil, fmt.Errorf("unknown confidential-image backend %q", cfg.Backend)
+	}
+}
+
+// unsupportedBuilder is a placeholder for backends that are not yet
+// implemented; it fails clearly rather than silently falling back to
+// SEV-SNP behavior.
+type unsupportedBuilder struct {
+	backend string
+}
+
+func (u *unsupportedBuilder) Generate(buildTag string) (*Result, error) {
+	return nil, fmt.Errorf("confidential-image backend %q is not yet implemented", u.backend)
+}
+
+func (u *unsupportedBuilder) Register(digest string, result *Result) error {
+	return fmt.Errorf("confidential-image backend %q is not yet implemented", u.backend)
+}
@@ -0,0 +1,89 @@
+package confidential
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/google/uuid"
+)
+
+// sevSNPBuilder generates an AMD SEV-SNP confidential image using the
+// cw-build tooling and registers it with the attestation server over
+// HTTP, replacing the hardcoded shell-out path that used to live inline
+// in S2IBuilder.Build.
+type sevSNPBuilder struct {
+	cfg Config
+}
+
+func (b *sevSNPBuilder) Generate(buildTag string) (*Result, error) {
+	password := uuid.New().String()
+
+	if _, err := exec.Command("/usr/bin/cw-build", buildTag, password).Output(); err != nil {
+		return nil, fmt.Errorf("cw-build failed: %v", err)
+	}
+
+	env, err := exec.Command("python3", "/usr/bin/extract_env.py", buildTag).Output()
+	if err != nil {
+		return nil, fmt.Errorf("env extraction failed: %v", err)
+	}
+	workDir, err := exec.Command("buildah", "inspect", "--format='{{.OCIv1.Config.WorkingDir}}'", buildTag).Output()
+	if err != nil {
+		return nil, fmt.Errorf("workdir extraction failed: %v", err)
+	}
+
+	tmpl, err := template.New("kernel-cmdline").Parse(b.cfg.KernelCmdlineTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kernel command-line template: %v", err)
+	}
+	var cmdline bytes.Buffer
+	if err := tmpl.Execute(&cmdline, struct {
+		Password string
+		WorkDir  string
+		Env      string
+	}{Password: password, WorkDir: strings.ReplaceAll(string(workDir), "'", ""), Env: string(env)}); err != nil {
+		return nil, fmt.Errorf("rendering kernel command-line: %v", err)
+	}
+
+	return &Result{ImageID: buildTag, KernelCmdline: cmdline.String()}, nil
+}
+
+func (b *sevSNPBuilder) Register(digest string, result *Result) error {
+	payload := struct {
+		SHA           string `json:"sha"`
+		Name          string `json:"name"`
+		KernelCmdLine string `json:"kernel_cmd_line"`
+	}{
+		SHA:           digest,
+		Name:          strings.ReplaceAll(result.ImageID, ":latest", ""),
+		KernelCmdLine: base64.StdEncoding.EncodeToString([]byte(result.KernelCmdline)),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", b.cfg.AttestationServerURL+"/confidential/register-image", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(b.cfg.AttestationServerAuth) != 0 {
+		req.Header.Set("Authorization", b.cfg.AttestationServerAuth)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("registering confidential image: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("attestation server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package imagebackend
+
+import (
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	dockerclient "github.com/fsouza/go-dockerclient"
+)
+
+// dockerConfigFromOCI adapts the config fields builder actually reads
+// (User, OnBuild, Labels) from an OCI image config into the
+// go-dockerclient Config shape, so both backends expose the same Image
+// type regardless of which one produced it.
+func dockerConfigFromOCI(oci *imgspecv1.Image, labels map[string]string) dockerclient.Config {
+	return dockerclient.Config{
+		User:    oci.Config.User,
+		OnBuild: oci.Config.OnBuild,
+		Labels:  labels,
+	}
+}
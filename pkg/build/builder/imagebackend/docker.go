@@ -0,0 +1,42 @@
+package imagebackend
+
+import (
+	"io"
+	"strings"
+
+	dockerclient "github.com/fsouza/go-dockerclient"
+)
+
+// dockerBackend is the historical Backend implementation, delegating
+// everything to a Docker daemon through DockerClient.
+type dockerBackend struct {
+	client DockerClient
+}
+
+func (b *dockerBackend) InspectImage(ref string) (*Image, error) {
+	image, err := b.client.InspectImage(ref)
+	if err != nil {
+		return nil, err
+	}
+	return &Image{ID: image.ID, ContainerConfig: *image.ContainerConfig, Config: *image.Config}, nil
+}
+
+func (b *dockerBackend) PullImage(ref string, searchPaths []string, progress io.Writer) error {
+	repository, tag := dockerclient.ParseRepositoryTag(ref)
+	opts := dockerclient.PullImageOptions{Repository: repository, Tag: tag}
+	if opts.Tag == "" && strings.Contains(ref, "@") {
+		// ParseRepositoryTag doesn't split digest references apart from
+		// the repository; pass ref through whole so the daemon resolves
+		// it by digest.
+		opts.Repository = ref
+	}
+	if progress != nil {
+		opts.OutputStream = progress
+	}
+	return b.client.PullImage(opts, searchPaths)
+}
+
+func (b *dockerBackend) TagImage(src, dst string) error {
+	repository, tag := dockerclient.ParseRepositoryTag(dst)
+	return b.client.TagImage(src, dockerclient.TagImageOptions{Repo: repository, Tag: tag, Force: true})
+}
@@ -0,0 +1,126 @@
+package imagebackend
+
+import (
+	"fmt"
+	"testing"
+
+	dockerclient "github.com/fsouza/go-dockerclient"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestNewDispatchesByName(t *testing.T) {
+	tests := []struct {
+		name     Name
+		wantType string
+	}{
+		{Docker, "*imagebackend.dockerBackend"},
+		{"", "*imagebackend.dockerBackend"},
+		{Podman, "*imagebackend.storageBackend"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.name)+"_or_default", func(t *testing.T) {
+			backend, err := New(tt.name, nil, "", "", nil)
+			if err != nil {
+				t.Fatalf("New(%q): %v", tt.name, err)
+			}
+			if got := fmt.Sprintf("%T", backend); got != tt.wantType {
+				t.Errorf("New(%q) = %s, want %s", tt.name, got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestNewRejectsUnknownBackend(t *testing.T) {
+	if _, err := New("bogus", nil, "", "", nil); err == nil {
+		t.Fatal("expected an error for an unknown backend name")
+	}
+}
+
+// fakeDockerClient is a minimal DockerClient fake for exercising
+// dockerBackend without a real daemon.
+type fakeDockerClient struct {
+	inspectImage func(name string) (*dockerclient.Image, error)
+	pullImage    func(opts dockerclient.PullImageOptions, searchPaths []string) error
+	tagImage     func(name string, opts dockerclient.TagImageOptions) error
+}
+
+func (f *fakeDockerClient) InspectImage(name string) (*dockerclient.Image, error) {
+	return f.inspectImage(name)
+}
+
+func (f *fakeDockerClient) PullImage(opts dockerclient.PullImageOptions, searchPaths []string) error {
+	return f.pullImage(opts, searchPaths)
+}
+
+func (f *fakeDockerClient) TagImage(name string, opts dockerclient.TagImageOptions) error {
+	return f.tagImage(name, opts)
+}
+
+func TestDockerBackendInspectImage(t *testing.T) {
+	client := &fakeDockerClient{
+		inspectImage: func(name string) (*dockerclient.Image, error) {
+			return &dockerclient.Image{
+				ID:              "sha256:abc",
+				ContainerConfig: &dockerclient.Config{User: "1001", Labels: map[string]string{"io.openshift.s2i.destination": "/opt/app-root"}},
+				Config:          &dockerclient.Config{},
+			}, nil
+		},
+	}
+	backend := &dockerBackend{client: client}
+
+	image, err := backend.InspectImage("registry.example.com/ns/ruby:latest")
+	if err != nil {
+		t.Fatalf("InspectImage: %v", err)
+	}
+	if image.ID != "sha256:abc" {
+		t.Errorf("ID = %q, want %q", image.ID, "sha256:abc")
+	}
+	if image.ContainerConfig.User != "1001" {
+		t.Errorf("ContainerConfig.User = %q, want %q", image.ContainerConfig.User, "1001")
+	}
+}
+
+func TestDockerBackendPullImageDigestReference(t *testing.T) {
+	var gotRepository string
+	client := &fakeDockerClient{
+		pullImage: func(opts dockerclient.PullImageOptions, searchPaths []string) error {
+			gotRepository = opts.Repository
+			return nil
+		},
+	}
+	backend := &dockerBackend{client: client}
+
+	ref := "registry.example.com/ns/ruby@sha256:deadbeef"
+	if err := backend.PullImage(ref, nil, nil); err != nil {
+		t.Fatalf("PullImage: %v", err)
+	}
+	if gotRepository != ref {
+		t.Errorf("pulled repository = %q, want the full digest reference %q", gotRepository, ref)
+	}
+}
+
+func TestDockerBackendPullImagePropagatesError(t *testing.T) {
+	client := &fakeDockerClient{
+		pullImage: func(opts dockerclient.PullImageOptions, searchPaths []string) error {
+			return fmt.Errorf("registry unavailable")
+		},
+	}
+	backend := &dockerBackend{client: client}
+	if err := backend.PullImage("registry.example.com/ns/ruby:latest", nil, nil); err == nil {
+		t.Fatal("expected PullImage to propagate the client's error")
+	}
+}
+
+func TestDockerConfigFromOCI(t *testing.T) {
+	oci := &imgspecv1.Image{}
+	oci.Config.User = "1001"
+	oci.Config.OnBuild = []string{"RUN echo hello"}
+
+	got := dockerConfigFromOCI(oci, map[string]string{"io.openshift.s2i.destination": "/opt/app-root"})
+	if got.User != "1001" {
+		t.Errorf("User = %q, want %q", got.User, "1001")
+	}
+	if len(got.OnBuild) != 1 || got.OnBuild[0] != "RUN echo hello" {
+		t.Errorf("OnBuild = %v, want [%q]", got.OnBuild, "RUN echo hello")
+	}
+}
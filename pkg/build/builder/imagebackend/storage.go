@@ -0,0 +1,191 @@
+package imagebackend
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/signature"
+	storageTransport "github.com/containers/image/v5/storage"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+	cstorage "github.com/containers/storage"
+	"github.com/containers/storage/pkg/unshare"
+
+	"github.com/openshift/builder/pkg/build/builder/imageref"
+	"github.com/openshift/builder/pkg/build/builder/registryauth"
+	"github.com/openshift/builder/pkg/build/builder/registrypolicy"
+)
+
+// storageBackend is the daemonless Backend implementation: it inspects
+// and pulls images directly through containers/image into a local
+// containers/storage store, so builder can run without a Docker daemon.
+// It supports the same rootless storage paths Podman uses when run as a
+// non-root user.
+type storageBackend struct {
+	dockerSocket string // retained for API symmetry with dockerBackend; unused daemonlessly
+
+	store  cstorage.Store
+	auth   *registryauth.Resolver
+	policy *registrypolicy.Resolver
+}
+
+// resolveSource builds the types.SystemContext and effective source
+// reference to use for ref: credentials from auth, and, from policy,
+// TLS verification and mirror rewriting. It returns an error instead of
+// a reference when ref's registry is blocked by registries.conf. Unlike
+// the Docker-daemon backend, which defers entirely to the daemon's own
+// registries.conf handling, this backend talks to registries directly
+// and so has to apply that policy itself.
+func (b *storageBackend) resolveSource(ref string) (*types.SystemContext, string, error) {
+	sys := &types.SystemContext{}
+	parsed, err := imageref.ParseImageRef(ref)
+	if err != nil {
+		return sys, ref, nil
+	}
+
+	if b.auth != nil {
+		authConfig := b.auth.Resolve(parsed.Domain)
+		if len(authConfig.Username) != 0 || len(authConfig.Password) != 0 {
+			sys.DockerAuthConfig = &types.DockerAuthConfig{
+				Username: authConfig.Username,
+				Password: authConfig.Password,
+			}
+		}
+	}
+
+	if b.policy == nil {
+		return sys, ref, nil
+	}
+	decision, err := b.policy.Resolve(parsed.Domain)
+	if err != nil {
+		return sys, ref, nil
+	}
+	if decision.Blocked {
+		return nil, "", fmt.Errorf("registry %s is blocked by registries.conf policy", parsed.Domain)
+	}
+	if !decision.TLSVerify {
+		sys.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+	}
+	if decision.Location != parsed.Domain {
+		parsed.Domain = decision.Location
+		ref = parsed.String()
+	}
+	return sys, ref, nil
+}
+
+func (b *storageBackend) ensureStore() (cstorage.Store, error) {
+	if b.store != nil {
+		return b.store, nil
+	}
+	opts, err := cstorage.DefaultStoreOptions(unshare.IsRootless(), unshare.GetRootlessUID())
+	if err != nil {
+		return nil, fmt.Errorf("resolving containers/storage options: %v", err)
+	}
+	store, err := cstorage.GetStore(opts)
+	if err != nil {
+		return nil, fmt.Errorf("opening containers/storage store: %v", err)
+	}
+	b.store = store
+	return store, nil
+}
+
+func (b *storageBackend) InspectImage(ref string) (*Image, error) {
+	if _, err := b.ensureStore(); err != nil {
+		return nil, err
+	}
+	sys, resolvedRef, err := b.resolveSource(ref)
+	if err != nil {
+		return nil, err
+	}
+	srcRef, err := alltransports.ParseImageName("containers-storage:" + ref)
+	if err != nil {
+		// Fall back to a bare docker:// reference so callers can pass
+		// either a storage-qualified or plain image name.
+		srcRef, err = docker.ParseReference("//" + resolvedRef)
+		if err != nil {
+			return nil, fmt.Errorf("parsing image reference %q: %v", ref, err)
+		}
+	}
+	src, err := srcRef.NewImageSource(context.Background(), sys)
+	if err != nil {
+		return nil, fmt.Errorf("opening image source for %q: %v", ref, err)
+	}
+	defer src.Close()
+
+	unparsed := image.UnparsedInstance(src, nil)
+	img, err := image.FromUnparsedImage(context.Background(), sys, unparsed)
+	if err != nil {
+		return nil, fmt.Errorf("reading image %q: %v", ref, err)
+	}
+	ociConfig, err := img.OCIConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("reading image config for %q: %v", ref, err)
+	}
+
+	labels := ociConfig.Config.Labels
+	return &Image{
+		ID:              ref,
+		ContainerConfig: dockerConfigFromOCI(ociConfig, labels),
+		Config:          dockerConfigFromOCI(ociConfig, labels),
+	}, nil
+}
+
+func (b *storageBackend) PullImage(ref string, searchPaths []string, progress io.Writer) error {
+	store, err := b.ensureStore()
+	if err != nil {
+		return err
+	}
+
+	sys, resolvedRef, err := b.resolveSource(ref)
+	if err != nil {
+		return err
+	}
+
+	srcRef, err := alltransports.ParseImageName("docker://" + resolvedRef)
+	if err != nil {
+		return fmt.Errorf("parsing image reference %q: %v", resolvedRef, err)
+	}
+	// destRef keeps the original, unrewritten ref: a pull via a
+	// configured mirror still tags the result locally under the name
+	// the caller asked for.
+	destRef, err := storageTransport.Transport.ParseStoreReference(store, ref)
+	if err != nil {
+		return fmt.Errorf("resolving local storage reference for %q: %v", ref, err)
+	}
+
+	// Base-image signature policy is already enforced up front by
+	// imagesign.VerifyImage; accept anything here so a pull isn't
+	// rejected twice by two independently configured policies.
+	policyCtx, err := signature.NewPolicyContext(&signature.Policy{
+		Default: signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()},
+	})
+	if err != nil {
+		return fmt.Errorf("building policy context: %v", err)
+	}
+	defer policyCtx.Destroy()
+
+	_, err = copy.Image(context.Background(), policyCtx, destRef, srcRef, &copy.Options{
+		ReportWriter: progress,
+		SourceCtx:    sys,
+	})
+	if err != nil {
+		return fmt.Errorf("pulling %q: %v", ref, err)
+	}
+	return nil
+}
+
+func (b *storageBackend) TagImage(src, dst string) error {
+	store, err := b.ensureStore()
+	if err != nil {
+		return err
+	}
+	img, err := store.Image(src)
+	if err != nil {
+		return fmt.Errorf("looking up image %q in local storage: %v", src, err)
+	}
+	return store.SetNames(img.ID, append(img.Names, dst))
+}
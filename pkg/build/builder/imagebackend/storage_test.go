@@ -0,0 +1,125 @@
+package imagebackend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	cstorage "github.com/containers/storage"
+
+	"github.com/openshift/builder/pkg/build/builder/registrypolicy"
+)
+
+// newTestStore opens a containers/storage store rooted at a fresh temp
+// directory using the vfs graph driver, the same fallback Podman itself
+// uses for rootless storage when a better driver (overlay,
+// fuse-overlayfs) isn't available - no special privileges required.
+func newTestStore(t *testing.T) cstorage.Store {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := cstorage.GetStore(cstorage.StoreOptions{
+		GraphDriverName: "vfs",
+		GraphRoot:       dir,
+		RunRoot:         dir,
+	})
+	if err != nil {
+		t.Skipf("containers/storage vfs store unavailable in this environment: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := store.Shutdown(false); err != nil {
+			t.Logf("shutting down test store: %v", err)
+		}
+	})
+	return store
+}
+
+func TestStorageBackendTagImageUnknownImage(t *testing.T) {
+	b := &storageBackend{store: newTestStore(t)}
+	if err := b.TagImage("does-not-exist:latest", "renamed:latest"); err == nil {
+		t.Fatal("expected an error tagging an image that isn't in local storage")
+	}
+}
+
+func TestStorageBackendInspectImageUnknownImage(t *testing.T) {
+	b := &storageBackend{store: newTestStore(t)}
+	if _, err := b.InspectImage("does-not-exist:latest"); err == nil {
+		t.Fatal("expected an error inspecting an image that isn't in local storage or reachable as a registry reference")
+	}
+}
+
+// newTestPolicyResolver points a registrypolicy.Resolver at a
+// registries.conf containing conf, rather than whatever happens to be
+// installed on the test host.
+func newTestPolicyResolver(t *testing.T, conf string) *registrypolicy.Resolver {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "registries.conf")
+	if err := os.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatalf("writing test registries.conf: %v", err)
+	}
+	return registrypolicy.NewResolver(&types.SystemContext{SystemRegistriesConfPath: path}, nil)
+}
+
+func TestStorageBackendResolveSourceRejectsBlockedRegistry(t *testing.T) {
+	b := &storageBackend{policy: newTestPolicyResolver(t, `
+[[registry]]
+location = "blocked.example.com"
+blocked = true
+`)}
+
+	if _, _, err := b.resolveSource("blocked.example.com/ns/image:latest"); err == nil {
+		t.Fatal("expected resolveSource to reject a blocked registry")
+	}
+}
+
+func TestStorageBackendResolveSourceAppliesInsecure(t *testing.T) {
+	b := &storageBackend{policy: newTestPolicyResolver(t, `
+[[registry]]
+location = "insecure.example.com"
+insecure = true
+`)}
+
+	sys, resolvedRef, err := b.resolveSource("insecure.example.com/ns/image:latest")
+	if err != nil {
+		t.Fatalf("resolveSource: %v", err)
+	}
+	if sys.DockerInsecureSkipTLSVerify != types.OptionalBoolTrue {
+		t.Errorf("DockerInsecureSkipTLSVerify = %v, want true", sys.DockerInsecureSkipTLSVerify)
+	}
+	if resolvedRef != "insecure.example.com/ns/image:latest" {
+		t.Errorf("resolveSource rewrote ref to %q, want it unchanged", resolvedRef)
+	}
+}
+
+func TestStorageBackendResolveSourceAppliesMirrorToUnqualifiedRef(t *testing.T) {
+	b := &storageBackend{policy: newTestPolicyResolver(t, `
+[[registry]]
+location = "docker.io"
+
+[[registry.mirror]]
+location = "mirror.example.com"
+`)}
+
+	_, resolvedRef, err := b.resolveSource("ruby:2.7")
+	if err != nil {
+		t.Fatalf("resolveSource: %v", err)
+	}
+	if resolvedRef != "mirror.example.com/library/ruby:2.7" {
+		t.Errorf("resolveSource resolved unqualified ref to %q, want it rewritten to the configured mirror", resolvedRef)
+	}
+}
+
+func TestStorageBackendEnsureStoreReusesExistingStore(t *testing.T) {
+	b := &storageBackend{store: newTestStore(t)}
+	first, err := b.ensureStore()
+	if err != nil {
+		t.Fatalf("ensureStore: %v", err)
+	}
+	second, err := b.ensureStore()
+	if err != nil {
+		t.Fatalf("ensureStore (second call): %v", err)
+	}
+	if first != second {
+		t.Error("ensureStore should reuse the store already set on the backend rather than opening a new one")
+	}
+}
@@ -0,0 +1,65 @@
+// Package imageref provides a real reference type for image names,
+// replacing the ad-hoc string splitting (dockerclient.ParseRepositoryTag
+// plus strings.Contains(name, "@")) that used to be scattered around
+// image inspect/pull call sites.
+package imageref
+
+import (
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+)
+
+// Ref is a fully-parsed image reference: a registry domain, repository
+// path, and either a tag or a digest (never both, per the distribution
+// reference grammar).
+type Ref struct {
+	// Domain is the registry hostname, e.g. "quay.io" or
+	// "docker.io" for unqualified Docker Hub references.
+	Domain string
+	// Path is the repository path within Domain, e.g. "openshift/ruby".
+	Path string
+	// Tag is set when the reference is tag-qualified (the common case);
+	// empty when Digest is set instead.
+	Tag string
+	// Digest is set when the reference is digest-pinned
+	// (image@sha256:...); empty when Tag is set instead.
+	Digest string
+	// Familiar is the shortest form a user would type for this
+	// reference (e.g. "ruby:2.7" rather than
+	// "docker.io/library/ruby:2.7").
+	Familiar string
+}
+
+// String returns the canonical, fully-qualified form of the reference
+// (domain/path[:tag|@digest]), which is what should be logged so builds
+// are reproducible regardless of how the image was originally named.
+func (r Ref) String() string {
+	if len(r.Digest) != 0 {
+		return fmt.Sprintf("%s/%s@%s", r.Domain, r.Path, r.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Domain, r.Path, r.Tag)
+}
+
+// ParseImageRef parses name (tag-qualified, digest-qualified, or bare)
+// into a Ref, defaulting to the Docker Hub domain and the "latest" tag
+// the same way `docker pull` does for unqualified names.
+func ParseImageRef(name string) (Ref, error) {
+	named, err := reference.ParseDockerRef(name)
+	if err != nil {
+		return Ref{}, fmt.Errorf("invalid image reference %q: %v", name, err)
+	}
+
+	ref := Ref{
+		Domain:   reference.Domain(named),
+		Path:     reference.Path(named),
+		Familiar: reference.FamiliarString(named),
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		ref.Digest = digested.Digest().String()
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		ref.Tag = tagged.Tag()
+	}
+	return ref, nil
+}
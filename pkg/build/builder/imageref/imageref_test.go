@@ -0,0 +1,45 @@
+package imageref
+
+import "testing"
+
+func TestParseImageRef(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantDomain string
+		wantPath   string
+		wantTag    string
+		wantDigest string
+	}{
+		{"ruby", "docker.io", "library/ruby", "latest", ""},
+		{"ruby:2.7", "docker.io", "library/ruby", "2.7", ""},
+		{"quay.io/openshift/ruby:2.7", "quay.io", "openshift/ruby", "2.7", ""},
+		{"registry.example.com:5000/team/app:v1", "registry.example.com:5000", "team/app", "v1", ""},
+		{"ruby@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "docker.io", "library/ruby", "", "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+
+	for _, tc := range cases {
+		ref, err := ParseImageRef(tc.name)
+		if err != nil {
+			t.Errorf("ParseImageRef(%q) returned error: %v", tc.name, err)
+			continue
+		}
+		if ref.Domain != tc.wantDomain {
+			t.Errorf("ParseImageRef(%q).Domain = %q, want %q", tc.name, ref.Domain, tc.wantDomain)
+		}
+		if ref.Path != tc.wantPath {
+			t.Errorf("ParseImageRef(%q).Path = %q, want %q", tc.name, ref.Path, tc.wantPath)
+		}
+		if ref.Tag != tc.wantTag {
+			t.Errorf("ParseImageRef(%q).Tag = %q, want %q", tc.name, ref.Tag, tc.wantTag)
+		}
+		if ref.Digest != tc.wantDigest {
+			t.Errorf("ParseImageRef(%q).Digest = %q, want %q", tc.name, ref.Digest, tc.wantDigest)
+		}
+	}
+}
+
+func TestParseImageRefInvalid(t *testing.T) {
+	if _, err := ParseImageRef("UPPER case not allowed"); err == nil {
+		t.Error("expected an error for an invalid reference")
+	}
+}
@@ -0,0 +1,118 @@
+package imagesign
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+)
+
+// SignAndStore signs ref with cfg.SignBy and pushes the resulting
+// detached signature to the configured sigstore, so the image built from
+// this commit carries verifiable provenance.
+func SignAndStore(ctx context.Context, sys *types.SystemContext, cfg Config, ref string) error {
+	if !cfg.CanSign() {
+		return nil
+	}
+	sigStoreURL := cfg.SigstoreStorageURL
+	if len(sigStoreURL) == 0 {
+		return fmt.Errorf("no sigstore configured to store the signature for %q", ref)
+	}
+
+	passphrase, err := readPassphrase(cfg.SignPassphraseFile)
+	if err != nil {
+		return fmt.Errorf("reading signing passphrase: %v", err)
+	}
+
+	imgRef, err := alltransports.ParseImageName("docker://" + ref)
+	if err != nil {
+		return fmt.Errorf("parsing image reference %q: %v", ref, err)
+	}
+	src, err := imgRef.NewImageSource(ctx, sys)
+	if err != nil {
+		return fmt.Errorf("opening image source for %q: %v", ref, err)
+	}
+	defer src.Close()
+
+	manifestBytes, _, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("reading manifest for %q: %v", ref, err)
+	}
+
+	mech, err := signature.NewGPGSigningMechanism()
+	if err != nil {
+		return fmt.Errorf("initializing GPG signing: %v", err)
+	}
+	defer mech.Close()
+
+	sig, err := signature.SignDockerManifest(manifestBytes, ref, mech, cfg.SignBy, passphrase)
+	if err != nil {
+		return fmt.Errorf("signing %q: %v", ref, err)
+	}
+
+	return storeSignature(sigStoreURL, ref, sig)
+}
+
+func readPassphrase(path string) (string, error) {
+	if len(path) == 0 {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// storeSignature writes sig to the signature store at sigStoreURL,
+// following the same registries.conf `sigstore` layout
+// docker/distribution and skopeo use (one detached signature file per
+// manifest digest). Only a local directory (a bare path or a file://
+// URL) is supported; an HTTP(S) lookaside store is not implemented.
+func storeSignature(sigStoreURL, ref string, sig []byte) error {
+	base := sigStoreURL
+	if u, err := url.Parse(sigStoreURL); err == nil && len(u.Scheme) != 0 {
+		if u.Scheme != "file" {
+			return fmt.Errorf("sigstore storage URL %q uses scheme %q, which is not yet implemented (only local paths and file:// are)", sigStoreURL, u.Scheme)
+		}
+		base = u.Path
+	}
+
+	at := strings.LastIndex(ref, "@")
+	if at < 0 {
+		return fmt.Errorf("signing reference %q has no digest to key the stored signature on", ref)
+	}
+	repoPath, digest := ref[:at], ref[at+1:]
+	if colon := strings.LastIndex(repoPath, ":"); colon > strings.LastIndex(repoPath, "/") {
+		repoPath = repoPath[:colon]
+	}
+	// The lookaside convention skopeo/containers-image use keys the
+	// directory on "<algorithm>=<hex>" (e.g. sha256=abc...), not on the
+	// digest's own "algorithm:hex" form, since ':' isn't portable in a
+	// directory name on every filesystem.
+	digest = strings.Replace(digest, ":", "=", 1)
+
+	dir := filepath.Join(base, repoPath+"@"+digest)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating sigstore directory %s: %v", dir, err)
+	}
+
+	// Signatures are numbered starting at 1 and never overwritten, so
+	// re-signing the same digest (e.g. with a second key) adds to the
+	// set instead of clobbering an existing signature - the layout
+	// skopeo and docker/distribution both expect.
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("signature-%d", i))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return os.WriteFile(path, sig, 0o644)
+		} else if err != nil {
+			return fmt.Errorf("checking %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package registryauth resolves Docker/Podman-style credential files so
+// pulls against private registries work without builder needing its own
+// bespoke auth configuration. It is consulted by the same code path as
+// DockerClient.InspectImage and any pull call.
+package registryauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	dockerclient "github.com/fsouza/go-dockerclient"
+)
+
+// DefaultRegistry is used when an image reference has no explicit
+// registry domain, matching Docker Hub's canonical hostname.
+const DefaultRegistry = "index.docker.io"
+
+// dockerConfigFile mirrors the subset of ~/.docker/config.json builder
+// needs: per-registry auths plus the global credsStore/credHelpers
+// indirection to external credential helpers.
+type dockerConfigFile struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// Resolver loads credential files once and answers AuthConfig lookups by
+// registry hostname.
+type Resolver struct {
+	configs map[string]dockerclient.AuthConfiguration
+	// credHelpers maps a registry hostname to the name of the
+	// docker-credential-* helper binary that holds its credentials, for
+	// credsStore/credHelpers entries that defer to an external helper
+	// instead of storing a base64 auth blob inline.
+	credHelpers map[string]string
+}
+
+// DiscoveryPaths returns the ordered list of credential files Resolver
+// tries: an explicit --authfile override first, then
+// ~/.docker/config.json, then $XDG_RUNTIME_DIR/containers/auth.json.
+func DiscoveryPaths(authFileOverride string) []string {
+	var paths []string
+	if len(authFileOverride) != 0 {
+		paths = append(paths, authFileOverride)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".docker", "config.json"))
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); len(runtimeDir) != 0 {
+		paths = append(paths, filepath.Join(runtimeDir, "containers", "auth.json"))
+	}
+	return paths
+}
+
+// NewResolver loads and merges every credential file in paths (later
+// files do not override registries already resolved by an earlier one,
+// matching the discovery-order precedence above).
+func NewResolver(paths []string) (*Resolver, error) {
+	r := &Resolver{configs: map[string]dockerclient.AuthConfiguration{}, credHelpers: map[string]string{}}
+	for _, path := range paths {
+		if err := r.load(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("loading %s: %v", path, err)
+		}
+	}
+	return r, nil
+}
+
+func (r *Resolver) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg dockerConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parsing %s: %v", path, err)
+	}
+	for registry, entry := range cfg.Auths {
+		if _, exists := r.configs[registry]; exists {
+			continue
+		}
+		auth, err := decodeAuth(entry.Auth)
+		if err != nil {
+			return fmt.Errorf("decoding credentials for %s in %s: %v", registry, path, err)
+		}
+		auth.ServerAddress = registry
+		r.configs[registry] = auth
+	}
+	// credsStore/credHelpers delegate to an external `docker-credential-*`
+	// helper binary; record which helper owns which registry so it can
+	// be invoked lazily the first time that registry is looked up,
+	// rather than shelling out for every entry up front.
+	for registry, helper := range cfg.CredHelpers {
+		if _, exists := r.credHelpers[registry]; !exists {
+			r.credHelpers[registry] = helper
+		}
+	}
+	if _, exists := r.credHelpers["*"]; !exists && len(cfg.CredsStore) != 0 {
+		r.credHelpers["*"] = cfg.CredsStore
+	}
+	return nil
+}
+
+func decodeAuth(encoded string) (dockerclient.AuthConfiguration, error) {
+	if len(encoded) == 0 {
+		return dockerclient.AuthConfiguration{}, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return dockerclient.AuthConfiguration{}, err
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	auth := dockerclient.AuthConfiguration{Username: parts[0]}
+	if len(parts) == 2 {
+		auth.Password = parts[1]
+	}
+	return auth, nil
+}
+
+// AuthConfigurations returns every resolved AuthConfig keyed by registry
+// hostname, matching the shape DockerClient.InspectImage/pull expect.
+func (r *Resolver) AuthConfigurations() map[string]dockerclient.AuthConfiguration {
+	return r.configs
+}
+
+// Resolve returns the AuthConfig for registry. "docker.io" - the domain
+// imageref.ParseImageRef normalizes unqualified Docker Hub references to
+// - is treated as an alias for DefaultRegistry, since credential files
+// key Docker Hub entries under the historical index.docker.io hostname.
+// A registry with no dedicated entry and no matching credential helper
+// gets empty credentials: one registry's credentials are never handed to
+// another, even when that other registry is unconfigured.
+func (r *Resolver) Resolve(registry string) dockerclient.AuthConfiguration {
+	if registry == "docker.io" {
+		registry = DefaultRegistry
+	}
+	if auth, ok := r.configs[registry]; ok {
+		return auth
+	}
+	if helper, ok := r.helperFor(registry); ok {
+		if auth, err := runCredentialHelper(helper, registry); err == nil {
+			return auth
+		}
+	}
+	return dockerclient.AuthConfiguration{ServerAddress: registry}
+}
+
+func (r *Resolver) helperFor(registry string) (string, bool) {
+	if helper, ok := r.credHelpers[registry]; ok {
+		return helper, true
+	}
+	helper, ok := r.credHelpers["*"]
+	return helper, ok
+}
+
+// runCredentialHelper invokes `docker-credential-<helper> get`, the
+// protocol Docker and Podman both use for credsStore/credHelpers
+// entries, passing registry on stdin and parsing the username/password
+// out of its JSON response.
+func runCredentialHelper(helper, registry string) (dockerclient.AuthConfiguration, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	out, err := cmd.Output()
+	if err != nil {
+		return dockerclient.AuthConfiguration{}, fmt.Errorf("running docker-credential-%s: %v", helper, err)
+	}
+	var resp struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return dockerclient.AuthConfiguration{}, fmt.Errorf("parsing docker-credential-%s output: %v", helper, err)
+	}
+	return dockerclient.AuthConfiguration{ServerAddress: registry, Username: resp.Username, Password: resp.Secret}, nil
+}
@@ -0,0 +1,105 @@
+package registryauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir string, cfg dockerConfigFile) string {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshaling test config: %v", err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("writing test config: %v", err)
+	}
+	return path
+}
+
+func TestResolveFromAuths(t *testing.T) {
+	dir := t.TempDir()
+	cfg := dockerConfigFile{Auths: map[string]struct {
+		Auth string `json:"auth"`
+	}{
+		"registry.example.com": {Auth: base64.StdEncoding.EncodeToString([]byte("user:pass"))},
+	}}
+	path := writeConfig(t, dir, cfg)
+
+	r, err := NewResolver([]string{path})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+
+	auth := r.Resolve("registry.example.com")
+	if auth.Username != "user" || auth.Password != "pass" {
+		t.Errorf("Resolve returned %+v, want user/pass", auth)
+	}
+}
+
+func TestResolveDoesNotLeakDefaultRegistryCredentials(t *testing.T) {
+	dir := t.TempDir()
+	cfg := dockerConfigFile{Auths: map[string]struct {
+		Auth string `json:"auth"`
+	}{
+		DefaultRegistry: {Auth: base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass"))},
+	}}
+	path := writeConfig(t, dir, cfg)
+
+	r, err := NewResolver([]string{path})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+
+	// Docker Hub credentials must never be sent to an unconfigured,
+	// unrelated registry.
+	auth := r.Resolve("unknown.example.com")
+	if auth.Username != "" || auth.Password != "" {
+		t.Errorf("Resolve(\"unknown.example.com\") = %+v, want empty credentials", auth)
+	}
+}
+
+func TestResolveDockerIOAliasesIndexDockerIO(t *testing.T) {
+	dir := t.TempDir()
+	cfg := dockerConfigFile{Auths: map[string]struct {
+		Auth string `json:"auth"`
+	}{
+		DefaultRegistry: {Auth: base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass"))},
+	}}
+	path := writeConfig(t, dir, cfg)
+
+	r, err := NewResolver([]string{path})
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+
+	// "docker.io" is what imageref.ParseImageRef normalizes unqualified
+	// Docker Hub references to; credentials are still keyed under the
+	// historical index.docker.io hostname in config.json.
+	auth := r.Resolve("docker.io")
+	if auth.Username != "hubuser" || auth.Password != "hubpass" {
+		t.Errorf("Resolve(\"docker.io\") = %+v, want hubuser/hubpass", auth)
+	}
+}
+
+func TestResolveUnknownRegistryNoCredentials(t *testing.T) {
+	r, err := NewResolver(nil)
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	auth := r.Resolve("unknown.example.com")
+	if auth.Username != "" || auth.Password != "" {
+		t.Errorf("Resolve = %+v, want empty credentials", auth)
+	}
+}
+
+func TestDiscoveryPathsIncludesOverrideFirst(t *testing.T) {
+	paths := DiscoveryPaths("/custom/authfile.json")
+	if len(paths) == 0 || paths[0] != "/custom/authfile.json" {
+		t.Errorf("DiscoveryPaths = %v, want override first", paths)
+	}
+}
@@ -0,0 +1,70 @@
+// Package registrypolicy consults /etc/containers/registries.conf
+// (the containers/image v2 TOML format) so builder can automatically
+// flip TLS verification off for registries marked insecure, skip
+// blocked registries, and follow configured mirrors, instead of always
+// assuming a fully verified, unmirrored pull.
+package registrypolicy
+
+import (
+	"fmt"
+
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+)
+
+// Decision is the effective policy for a single pull/inspect against a
+// given registry domain.
+type Decision struct {
+	// TLSVerify reports whether TLS certificate verification should be
+	// performed; false for registries.conf entries with insecure=true,
+	// or when overridden via --tls-verify=false.
+	TLSVerify bool
+	// Blocked is true when the registry is listed as blocked in
+	// registries.conf; builder must refuse to pull from it.
+	Blocked bool
+	// Location is the domain/path pull requests should actually be
+	// rewritten to, honoring any configured mirrors; equal to the
+	// original domain when no mirror applies.
+	Location string
+}
+
+// Resolver evaluates registries.conf policy for image domains.
+type Resolver struct {
+	ctx *types.SystemContext
+	// tlsVerifyOverride, when non-nil, takes precedence over whatever
+	// registries.conf says (the --tls-verify flag).
+	tlsVerifyOverride *bool
+}
+
+// NewResolver creates a Resolver that reads registries.conf from the
+// default system locations (or the path in
+// types.SystemContext.SystemRegistriesConfPath, if ctx sets one).
+func NewResolver(ctx *types.SystemContext, tlsVerifyOverride *bool) *Resolver {
+	if ctx == nil {
+		ctx = &types.SystemContext{}
+	}
+	return &Resolver{ctx: ctx, tlsVerifyOverride: tlsVerifyOverride}
+}
+
+// Resolve returns the effective policy for domain (a registry hostname,
+// optionally with a port, as returned by imageref.Ref.Domain).
+func (r *Resolver) Resolve(domain string) (Decision, error) {
+	registry, err := sysregistriesv2.FindRegistry(r.ctx, domain)
+	if err != nil {
+		return Decision{}, fmt.Errorf("reading registries.conf for %s: %v", domain, err)
+	}
+
+	decision := Decision{TLSVerify: true, Location: domain}
+	if registry != nil {
+		decision.Blocked = registry.Blocked
+		decision.TLSVerify = !registry.Insecure
+		decision.Location = registry.Location
+		if len(registry.Mirrors) > 0 {
+			decision.Location = registry.Mirrors[0].Location
+		}
+	}
+	if r.tlsVerifyOverride != nil {
+		decision.TLSVerify = *r.tlsVerifyOverride
+	}
+	return decision, nil
+}
@@ -0,0 +1,118 @@
+package registrypolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+)
+
+// newTestResolver points a Resolver at a registries.conf containing
+// conf, rather than whatever happens to be installed on the test host.
+func newTestResolver(t *testing.T, conf string, tlsVerifyOverride *bool) *Resolver {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "registries.conf")
+	if err := os.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatalf("writing test registries.conf: %v", err)
+	}
+	return NewResolver(&types.SystemContext{SystemRegistriesConfPath: path}, tlsVerifyOverride)
+}
+
+func TestResolveDefaultsToFullyVerifiedUnmirrored(t *testing.T) {
+	r := newTestResolver(t, ``, nil)
+
+	decision, err := r.Resolve("registry.example.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !decision.TLSVerify {
+		t.Error("TLSVerify = false, want true for a registry with no matching entry")
+	}
+	if decision.Blocked {
+		t.Error("Blocked = true, want false for a registry with no matching entry")
+	}
+	if decision.Location != "registry.example.com" {
+		t.Errorf("Location = %q, want the original domain unchanged", decision.Location)
+	}
+}
+
+func TestResolveAppliesInsecureAndBlocked(t *testing.T) {
+	r := newTestResolver(t, `
+[[registry]]
+location = "insecure.example.com"
+insecure = true
+
+[[registry]]
+location = "blocked.example.com"
+blocked = true
+`, nil)
+
+	decision, err := r.Resolve("insecure.example.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if decision.TLSVerify {
+		t.Error("TLSVerify = true, want false for a registry marked insecure")
+	}
+
+	decision, err = r.Resolve("blocked.example.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !decision.Blocked {
+		t.Error("Blocked = false, want true for a registry marked blocked")
+	}
+}
+
+// TestResolveAppliesLocationRemap covers a prefix/location entry with no
+// [[registry.mirror]] block - the standard registries.conf pattern for
+// remapping a registry wholesale (e.g. to a local proxy) rather than
+// just mirroring it. Location must reflect the remap target, not the
+// matched prefix the entry was keyed on.
+func TestResolveAppliesLocationRemap(t *testing.T) {
+	r := newTestResolver(t, `
+[[registry]]
+prefix = "registry.access.redhat.com"
+location = "remapped.example.com"
+`, nil)
+
+	decision, err := r.Resolve("registry.access.redhat.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if decision.Location != "remapped.example.com" {
+		t.Errorf("Location = %q, want the registry's remap target %q", decision.Location, "remapped.example.com")
+	}
+}
+
+func TestResolveAppliesMirror(t *testing.T) {
+	r := newTestResolver(t, `
+[[registry]]
+location = "docker.io"
+
+[[registry.mirror]]
+location = "mirror.example.com"
+`, nil)
+
+	decision, err := r.Resolve("docker.io")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if decision.Location != "mirror.example.com" {
+		t.Errorf("Location = %q, want the configured mirror %q", decision.Location, "mirror.example.com")
+	}
+}
+
+func TestResolveTLSVerifyOverrideTakesPrecedence(t *testing.T) {
+	tlsVerify := false
+	r := newTestResolver(t, ``, &tlsVerify)
+
+	decision, err := r.Resolve("registry.example.com")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if decision.TLSVerify {
+		t.Error("TLSVerify = true, want the override (false) to take precedence over registries.conf")
+	}
+}
@@ -3,18 +3,17 @@ package builder
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/containers/image/v5/types"
 	dockerclient "github.com/fsouza/go-dockerclient"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,16 +28,21 @@ import (
 	"github.com/openshift/source-to-image/pkg/api/validation"
 	s2ibuild "github.com/openshift/source-to-image/pkg/build"
 	s2i "github.com/openshift/source-to-image/pkg/build/strategies"
+	"github.com/openshift/source-to-image/pkg/build/strategies/onbuild"
 	"github.com/openshift/source-to-image/pkg/docker"
 	s2igit "github.com/openshift/source-to-image/pkg/scm/git"
 	s2iutil "github.com/openshift/source-to-image/pkg/util"
 
 	"github.com/openshift/builder/pkg/build/builder/cmd/dockercfg"
+	"github.com/openshift/builder/pkg/build/builder/confidential"
+	"github.com/openshift/builder/pkg/build/builder/imagebackend"
+	"github.com/openshift/builder/pkg/build/builder/imageref"
+	"github.com/openshift/builder/pkg/build/builder/imagesign"
+	"github.com/openshift/builder/pkg/build/builder/registryauth"
+	"github.com/openshift/builder/pkg/build/builder/registrypolicy"
 	"github.com/openshift/builder/pkg/build/builder/timing"
 	builderutil "github.com/openshift/builder/pkg/build/builder/util"
 	"github.com/openshift/builder/pkg/build/builder/util/dockerfile"
-
-	"github.com/google/uuid"
 )
 
 // builderFactory is the internal interface to decouple S2I-specific code from Origin builder code
@@ -53,19 +57,58 @@ type validator interface {
 	ValidateConfig(config *s2iapi.Config) []validation.Error
 }
 
-// runtimeBuilderFactory is the default implementation of stiBuilderFactory
+// runtimeBuilderFactory is the default implementation of builderFactory.
+// It dispatches between the standard S2I strategy and the onbuild
+// strategy based on the Build's requested SourceStrategy.Strategy and,
+// for Auto, on whether the builder image itself declares ONBUILD
+// instructions.
 type runtimeBuilderFactory struct {
-	dockerClient DockerClient
+	backend  imagebackend.Backend
+	strategy buildapiv1.SourceBuildStrategyType
 }
 
-// Builder delegates execution to S2I-specific code
+// Builder delegates execution to S2I-specific code, or to the onbuild
+// strategy when the builder image has ONBUILD triggers and the build
+// hasn't blocked that fallback.
 func (r runtimeBuilderFactory) Builder(config *s2iapi.Config, overrides s2ibuild.Overrides) (s2ibuild.Builder, s2iapi.BuildInfo, error) {
 	var client docker.Client
 	var err error
+
+	useOnBuild := r.strategy == buildapiv1.SourceBuildStrategyTypeOnBuild
+	if r.strategy == "" || r.strategy == buildapiv1.SourceBuildStrategyTypeAuto {
+		hasOnBuild, err := imageHasOnBuild(r.backend, config.BuilderImage)
+		if err != nil {
+			return nil, s2iapi.BuildInfo{}, err
+		}
+		useOnBuild = hasOnBuild && !config.BlockOnBuild
+	}
+
+	if useOnBuild {
+		builder, buildInfo, err := onbuild.New(client, config, overrides)
+		return builder, buildInfo, err
+	}
+
 	builder, buildInfo, err := s2i.Strategy(client, config, overrides)
 	return builder, buildInfo, err
 }
 
+// imageHasOnBuild reports whether the named image declares ONBUILD
+// instructions, which is what upstream strategies.GetStrategy uses to
+// pick the onbuild flow over the standard assemble/run S2I flow. It
+// goes through backend like every other inspect in this file, so it
+// reflects what was actually pulled rather than reaching past a
+// daemonless backend to query the Docker daemon directly.
+func imageHasOnBuild(backend imagebackend.Backend, imageTag string) (bool, error) {
+	if backend == nil {
+		return false, nil
+	}
+	image, err := backend.InspectImage(imageTag)
+	if err != nil {
+		return false, err
+	}
+	return len(image.Config.OnBuild) > 0, nil
+}
+
 // runtimeConfigValidator is the default implementation of stiConfigValidator
 type runtimeConfigValidator struct{}
 
@@ -83,18 +126,63 @@ type S2IBuilder struct {
 	build        *buildapiv1.Build
 	client       buildclientv1.BuildInterface
 	cgLimits     *s2iapi.CGroupLimits
+	progress     timing.ProgressReporter
+	signing      imagesign.Config
+	backend      imagebackend.Backend
+}
+
+// SetImageBackend replaces the default Docker-daemon image backend with
+// the one selected by name (e.g. imagebackend.Podman for daemonless
+// inspect/pull against local containers/storage), honoring
+// --image-backend. authFile overrides the default Docker/Podman
+// credential-file discovery order (--authfile), and tlsVerifyOverride
+// overrides registries.conf's insecure setting (--tls-verify), for
+// backends that talk to registries directly. Like SetSigningConfig, this
+// is a first-class option so pipelines assembling an S2IBuilder directly
+// can select it without going through cmd.
+func (s *S2IBuilder) SetImageBackend(name imagebackend.Name, authFile string, tlsVerifyOverride *bool) error {
+	backend, err := imagebackend.New(name, s.dockerClient, s.dockerSocket, authFile, tlsVerifyOverride)
+	if err != nil {
+		return err
+	}
+	s.backend = backend
+	if factory, ok := s.builder.(runtimeBuilderFactory); ok {
+		factory.backend = backend
+		s.builder = factory
+	}
+	return nil
+}
+
+// SetSigningConfig configures base-image signature verification and
+// post-commit signing for subsequent calls to Build. It is a first-class
+// option rather than something only reachable via CLI flags, so
+// pipelines assembling an S2IBuilder directly can enforce provenance
+// without going through cmd.
+func (s *S2IBuilder) SetSigningConfig(cfg imagesign.Config) {
+	s.signing = cfg
 }
 
 // NewS2IBuilder creates a new STIBuilder instance
 func NewS2IBuilder(dockerClient DockerClient, dockerSocket string, buildsClient buildclientv1.BuildInterface, build *buildapiv1.Build,
 	cgLimits *s2iapi.CGroupLimits) *S2IBuilder {
 	// delegate to internal implementation passing default implementation of builderFactory and validator
-	return newS2IBuilder(dockerClient, dockerSocket, buildsClient, build, runtimeBuilderFactory{dockerClient}, runtimeConfigValidator{}, cgLimits)
+	var strategy buildapiv1.SourceBuildStrategyType
+	if build.Spec.Strategy.SourceStrategy != nil {
+		strategy = build.Spec.Strategy.SourceStrategy.Strategy
+	}
+	// Matches the default backend newS2IBuilder sets on S2IBuilder.backend
+	// below; SetImageBackend must be called before Build/Rebuild run for a
+	// backend switch to also take effect here.
+	backend, _ := imagebackend.New(imagebackend.Docker, dockerClient, dockerSocket, "", nil)
+	return newS2IBuilder(dockerClient, dockerSocket, buildsClient, build, runtimeBuilderFactory{backend: backend, strategy: strategy}, runtimeConfigValidator{}, cgLimits)
 }
 
 // newS2IBuilder is the internal factory function to create STIBuilder based on parameters. Used for testing.
 func newS2IBuilder(dockerClient DockerClient, dockerSocket string, buildsClient buildclientv1.BuildInterface, build *buildapiv1.Build,
 	builder builderFactory, validator validator, cgLimits *s2iapi.CGroupLimits) *S2IBuilder {
+	// Defaults to the Docker-daemon backend, matching the historical
+	// behavior; SetImageBackend switches it to the daemonless backend.
+	backend, _ := imagebackend.New(imagebackend.Docker, dockerClient, dockerSocket, "", nil)
 	// just create instance
 	return &S2IBuilder{
 		builder:      builder,
@@ -104,6 +192,7 @@ func newS2IBuilder(dockerClient DockerClient, dockerSocket string, buildsClient
 		build:        build,
 		client:       buildsClient,
 		cgLimits:     cgLimits,
+		backend:      backend,
 	}
 }
 
@@ -140,10 +229,18 @@ func (s *S2IBuilder) Build() error {
 	var err error
 	ctx := timing.NewContext(context.Background())
 	defer func() {
+		if s.progress != nil {
+			s.progress.Flush()
+		}
 		s.build.Status.Stages = timing.AppendStageAndStepInfo(s.build.Status.Stages, timing.GetStages(ctx))
 		HandleBuildStatusUpdate(s.build, s.client, nil)
 	}()
 
+	s.progress = timing.NewThrottledReporter(2*time.Second, func(p timing.Progress) {
+		s.build.Status.BuildProgress = p.String()
+		HandleBuildStatusUpdate(s.build, s.client, nil)
+	})
+
 	if s.build.Spec.Strategy.SourceStrategy == nil {
 		return errors.New("the source to image builder must be used with the source strategy")
 	}
@@ -223,26 +320,34 @@ func (s *S2IBuilder) Build() error {
 		AsDockerfile: "/tmp/dockercontext/Dockerfile",
 
 		ScriptDownloadProxyConfig: scriptDownloadProxyConfig,
-		BlockOnBuild:              true,
+		BlockOnBuild:              s.build.Spec.Strategy.SourceStrategy.Strategy == buildapiv1.SourceBuildStrategyTypeS2I,
 
 		KeepSymlinks: true,
 	}
 
+	if err := imagesign.VerifyImage(ctx, signingSystemContext(config.BuilderImage), s.signing, config.BuilderImage); err != nil {
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointVerifyImage, err.Error())
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		return err
+	}
+
 	// If DockerCfgPath is provided in buildapiv1.Config, then attempt to read the
 	// dockercfg file and get the authentication for pulling the images.
 
-	if s.build.Spec.Strategy.SourceStrategy.ForcePull || !isImagePresent(s.dockerClient, config.BuilderImage) {
+	if s.build.Spec.Strategy.SourceStrategy.ForcePull || !isImagePresent(s.backend, config.BuilderImage) {
 		startTime := metav1.Now()
 		searchPaths := dockercfg.NewHelper().GetDockerAuthSearchPaths(dockercfg.PullAuthType)
 		err = s.pullImage(config.BuilderImage, searchPaths)
 		timing.RecordNewStep(ctx, buildapiv1.StagePullImages, buildapiv1.StepPullBaseImage, startTime, metav1.Now())
 		if err != nil {
+			builderutil.SetFailureReason(s.build, builderutil.FailurePointPullBuilderImage, err.Error())
+			s.build.Status.Phase = buildapiv1.BuildPhaseFailed
 			return err
 		}
 	}
 
 	if config.Incremental {
-		if s.build.Spec.Strategy.SourceStrategy.ForcePull || !isImagePresent(s.dockerClient, config.IncrementalFromTag) {
+		if s.build.Spec.Strategy.SourceStrategy.ForcePull || !isImagePresent(s.backend, config.IncrementalFromTag) {
 			// Per @bparees the dockercfg.PushTypeAuth is needed to use the same credentials/authentication that
 			// we used to push the image previously.
 			searchPaths := dockercfg.NewHelper().GetDockerAuthSearchPaths(dockercfg.PushAuthType)
@@ -264,7 +369,7 @@ func (s *S2IBuilder) Build() error {
 		}
 	}
 
-	assembleUser, err := getAssembleUser(s.dockerClient, config.BuilderImage)
+	assembleUser, err := getAssembleUser(s.backend, config.BuilderImage)
 	if err != nil {
 		return err
 	}
@@ -273,7 +378,7 @@ func (s *S2IBuilder) Build() error {
 		config.AssembleUser = assembleUser
 	}
 
-	labels, err := getImageLabels(s.dockerClient, config.BuilderImage)
+	labels, err := getImageLabels(s.backend, config.BuilderImage)
 	if err != nil {
 		return err
 	}
@@ -290,6 +395,12 @@ func (s *S2IBuilder) Build() error {
 		}
 	}
 
+	hasOnBuild, err := imageHasOnBuild(s.backend, config.BuilderImage)
+	if err != nil {
+		return err
+	}
+	config.Labels[builderutil.DefaultDockerLabelNamespace+"build.has-onbuild"] = fmt.Sprintf("%t", hasOnBuild)
+
 	allowedUIDs := os.Getenv(builderutil.AllowedUIDs)
 	log.V(4).Infof("The value of %s is [%s]", builderutil.AllowedUIDs, allowedUIDs)
 	if len(allowedUIDs) > 0 {
@@ -305,6 +416,8 @@ func (s *S2IBuilder) Build() error {
 			buffer.WriteString(ve.Error())
 			buffer.WriteString(", ")
 		}
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointValidateConfig, buffer.String())
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
 		return errors.New(buffer.String())
 	}
 
@@ -315,10 +428,7 @@ func (s *S2IBuilder) Build() error {
 	builder, buildInfo, err := s.builder.Builder(config, s2ibuild.Overrides{Downloader: nil})
 	if err != nil {
 		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
-		s.build.Status.Reason, s.build.Status.Message = convertS2IFailureType(
-			buildInfo.FailureReason.Reason,
-			buildInfo.FailureReason.Message,
-		)
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointAssemble, string(buildInfo.FailureReason.Message))
 		HandleBuildStatusUpdate(s.build, s.client, nil)
 		return err
 	}
@@ -330,31 +440,45 @@ func (s *S2IBuilder) Build() error {
 	for _, stage := range result.BuildInfo.Stages {
 		for _, step := range stage.Steps {
 			timing.RecordNewStep(ctx, buildapiv1.StageName(stage.Name), buildapiv1.StepName(step.Name), metav1.NewTime(step.StartTime), metav1.NewTime(step.StartTime.Add(time.Duration(step.DurationMilliseconds)*time.Millisecond)))
+			if s.progress != nil {
+				s.progress.Report(timing.Progress{Stage: stage.Name, Step: step.Name})
+			}
 		}
 	}
 
 	if err != nil {
 		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		detail := ""
 		if result != nil {
-			s.build.Status.Reason, s.build.Status.Message = convertS2IFailureType(
-				result.BuildInfo.FailureReason.Reason,
-				result.BuildInfo.FailureReason.Message,
-			)
-		} else {
-			s.build.Status.Reason = buildapiv1.StatusReasonGenericBuildFailed
-			s.build.Status.Message = "Generic Build failure - check logs for details."
+			detail = string(result.BuildInfo.FailureReason.Message)
 		}
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointAssemble, detail)
 
 		HandleBuildStatusUpdate(s.build, s.client, nil)
 		return err
 	}
 
+	return s.commitAndPublish(ctx, config, buildTag, pushTag, push)
+}
+
+// commitAndPublish turns the Dockerfile S2I generated at
+// config.AsDockerfile into a Docker image tagged buildTag, runs the
+// post-commit hook, and - when push is true - tags and pushes the
+// result to pushTag, applying any confidential-image and signing steps
+// configured on s.build/s.signing along the way. Build and Rebuild both
+// call this, so a rebuild actually produces and publishes an image
+// instead of stopping at Dockerfile generation.
+func (s *S2IBuilder) commitAndPublish(ctx context.Context, config *s2iapi.Config, buildTag, pushTag string, push bool) error {
+	buildOutputStream := io.Writer(os.Stdout)
+	if s.progress != nil {
+		buildOutputStream = io.MultiWriter(os.Stdout, newDockerfileStepWriter(s.progress))
+	}
 	opts := dockerclient.BuildImageOptions{
 		Context:             ctx,
 		Name:                buildTag,
 		RmTmpContainer:      true,
 		ForceRmTmpContainer: true,
-		OutputStream:        os.Stdout,
+		OutputStream:        buildOutputStream,
 		Dockerfile:          defaultDockerfilePath,
 		NoCache:             false,
 		Pull:                s.build.Spec.Strategy.SourceStrategy.ForcePull,
@@ -389,81 +513,63 @@ func (s *S2IBuilder) Build() error {
 		if err != nil {
 			return err
 		}
-		// Append post commit
-		if err := appendPostCommit(node, buildPostCommit(s.build.Spec.PostCommit)); err != nil {
-			return err
-		}
 		out := dockerfile.Write(node)
 		log.V(4).Infof("Replacing dockerfile\n%s\nwith:\n%s", string(in), string(out))
 		overwriteFile(config.AsDockerfile, out)
 	}
 	// TODO pass ImageOptimization policy to the build?
-	err = s.dockerClient.BuildImage(opts)
+	err := s.dockerClient.BuildImage(opts)
 	timing.RecordNewStep(ctx, buildapiv1.StageBuild, buildapiv1.StepDockerBuild, startTime, metav1.Now())
 	if err != nil {
-		// TODO: Create new error states
 		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
-		s.build.Status.Reason = buildapiv1.StatusReasonGenericBuildFailed
-		s.build.Status.Message = builderutil.StatusMessageGenericBuildFailed
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointDockerBuild, err.Error())
 		return err
 	}
 
-	var cw_env = ""
-	var cw_workdir = ""
-	var cw_password = ""
-
-	if strings.Contains(buildTag, "-cw") {
-		cmd := exec.Command("python3", "/usr/bin/extract_env.py", buildTag)
-		stdout, err := cmd.Output()
-		if err != nil {
-			log.V(0).Infof("env extraction failed: %v", err)
-			return err
-		}
-		cw_env = string(stdout[:])
-		log.V(0).Infof("env: %s", cw_env)
-
-		cmd = exec.Command("buildah", "inspect", "--format='{{.OCIv1.Config.WorkingDir}}'", buildTag)
-		stdout, err = cmd.Output()
-		if err != nil {
-			log.V(0).Infof("workdir extraction failed: %v", err)
-			return err
-		}
-		cw_workdir = strings.ReplaceAll(string(stdout[:]), "'", "")
-		log.V(0).Infof("workdir: %s", cw_workdir)
-
-		cw_password = uuid.New().String()
-		cmd = exec.Command("/usr/bin/cw-build", buildTag, cw_password)
-		stdout, err = cmd.Output()
+	startTime = metav1.Now()
+	if err := runPostCommitHook(s.dockerClient, s.build, buildTag); err != nil {
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointPostCommit, err.Error())
+		HandleBuildStatusUpdate(s.build, s.client, nil)
+		return err
+	}
+	timing.RecordNewStep(ctx, buildapiv1.StagePostCommit, buildapiv1.StepExecPostCommitHook, startTime, metav1.Now())
+
+	var confidentialBuilder confidential.ConfidentialBuilder
+	var confidentialResult *confidential.Result
+	if backend, ok := s.build.Annotations[confidential.AnnotationConfidentialImage]; ok {
+		startTime = metav1.Now()
+		confidentialCfg := confidential.ConfigFromEnv()
+		confidentialCfg.Backend = backend
+		confidentialBuilder, err = confidential.NewConfidentialBuilder(confidentialCfg)
 		if err != nil {
-			log.V(0).Infof("cw image generation failed: %v", err)
+			s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+			builderutil.SetFailureReason(s.build, builderutil.FailurePointConfidentialImage, err.Error())
 			return err
 		}
-		log.V(0).Infof("cwbuild out: %s", stdout)
-
-		opts = dockerclient.BuildImageOptions{
-			Context:             ctx,
-			Name:                buildTag,
-			RmTmpContainer:      true,
-			ForceRmTmpContainer: true,
-			OutputStream:        os.Stdout,
-			Dockerfile:          defaultDockerfilePath,
-			NoCache:             false,
-			Pull:                s.build.Spec.Strategy.SourceStrategy.ForcePull,
-			ContextDir:          "/tmp/cwcontext",
-		}
 
-		log.V(0).Infof("generating an image with the encrypted disk")
-		err = s.dockerClient.BuildImage(opts)
+		confidentialResult, err = confidentialBuilder.Generate(buildTag)
 		if err != nil {
-			log.V(0).Infof("image generation failed: %v", err)
-			log.V(0).Infof("taking a nap")
-			time.Sleep(300 * time.Second)
+			s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+			builderutil.SetFailureReason(s.build, builderutil.FailurePointConfidentialImage, err.Error())
 			return err
 		}
+		timing.RecordNewStep(ctx, buildapiv1.StageConfidentialBuild, buildapiv1.StepGenerateConfidentialImage, startTime, metav1.Now())
 	}
 
 	if push {
-		if err = tagImage(s.dockerClient, buildTag, pushTag); err != nil {
+		// buildTag was just produced by s.dockerClient.BuildImage above,
+		// so it lives in the Docker daemon's store regardless of
+		// --image-backend; tag it there rather than through s.backend,
+		// which for the daemonless backend would look it up in local
+		// containers/storage instead and never find it. Build/commit
+		// still require a real Docker daemon - only inspect/pull are
+		// currently abstracted behind --image-backend.
+		repository, tag := dockerclient.ParseRepositoryTag(pushTag)
+		if err = s.dockerClient.TagImage(buildTag, dockerclient.TagImageOptions{Repo: repository, Tag: tag, Force: true}); err != nil {
+			s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+			builderutil.SetFailureReason(s.build, builderutil.FailurePointTagImage, err.Error())
+			HandleBuildStatusUpdate(s.build, s.client, nil)
 			return err
 		}
 		// Get the Docker push authentication
@@ -484,28 +590,32 @@ func (s *S2IBuilder) Build() error {
 
 		if err != nil {
 			s.build.Status.Phase = buildapiv1.BuildPhaseFailed
-			s.build.Status.Reason = buildapiv1.StatusReasonPushImageToRegistryFailed
-			s.build.Status.Message = builderutil.StatusMessagePushImageToRegistryFailed
+			builderutil.SetFailureReason(s.build, builderutil.FailurePointPushImage, "")
 			HandleBuildStatusUpdate(s.build, s.client, nil)
 			return reportPushFailure(err, authPresent, pushAuthConfig)
 		}
 
 		if len(digest) > 0 {
 			log.V(0).Infof("digest: %s", digest)
-			if cw_password != "" {
-				var kernelCmdLine = `KRUN_CFG=2:512 reboot=k panic=-1 panic_print=0 pci=off nomodules console=hvc0 rw no-kvmapf init=/bin/sh virtio_mmio.device=4K@0xd0000000:5 virtio_mmio.device=4K@0xd0001000:6 virtio_mmio.device=4K@0xd0002000:7 virtio_mmio.device=4K@0xd0003000:8 swiotlb=65536 KRUN_PASS=` + cw_password + ` KRUN_INIT=/usr/libexec/s2i/run KRUN_WORKDIR=` + cw_workdir + ` ` + cw_env
-
-				var jsonStr = []byte(`{"sha":"` + digest + `","name":"` + strings.ReplaceAll(pushTag, ":latest", "") + `","kernel_cmd_line":"` + base64.StdEncoding.EncodeToString([]byte(kernelCmdLine)) + `"}`)
-				log.V(0).Infof("jsonStr: %s", jsonStr)
-				req, err := http.NewRequest("POST", "http://registration-attestation-server.attestation:8080/confidential/register-image", bytes.NewBuffer(jsonStr))
-				req.Header.Set("Content-Type", "application/json")
-
-				client := &http.Client{}
-				resp, err := client.Do(req)
-				if err != nil {
-					log.V(0).Infof("error registering image: %v", err)
+			if s.signing.CanSign() {
+				startTime := metav1.Now()
+				if err := imagesign.SignAndStore(ctx, signingSystemContext(pushTag), s.signing, pushTag+"@"+digest); err != nil {
+					s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+					builderutil.SetFailureReason(s.build, builderutil.FailurePointSignImage, err.Error())
+					HandleBuildStatusUpdate(s.build, s.client, nil)
+					return err
+				}
+				timing.RecordNewStep(ctx, buildapiv1.StagePushImage, buildapiv1.StepSignImage, startTime, metav1.Now())
+			}
+			if confidentialBuilder != nil && confidentialResult != nil {
+				startTime := metav1.Now()
+				if err := confidentialBuilder.Register(digest, confidentialResult); err != nil {
+					s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+					builderutil.SetFailureReason(s.build, builderutil.FailurePointAttestationRegister, err.Error())
+					HandleBuildStatusUpdate(s.build, s.client, nil)
+					return err
 				}
-				defer resp.Body.Close()
+				timing.RecordNewStep(ctx, buildapiv1.StageConfidentialBuild, buildapiv1.StepRegisterConfidentialImage, startTime, metav1.Now())
 			}
 
 			s.build.Status.Output.To = &buildapiv1.BuildStatusOutputTo{
@@ -518,6 +628,169 @@ func (s *S2IBuilder) Build() error {
 	return nil
 }
 
+// rebuildLabelPrefix is the label namespace S2I writes onto images it
+// produces (see s2iconstants / s2iutil.GenerateLabelsFromSourceInfo), used
+// here to reconstruct a Config from a previously-built image rather than
+// from the original BuildConfig.
+const rebuildLabelPrefix = "io.openshift.s2i."
+
+// Rebuild executes an S2I build using a previously-built image as the
+// starting point instead of the original BuildConfig. It inspects the
+// image's io.openshift.s2i.* labels to recover the builder image, scripts
+// URL, source URL and context dir that produced it, then applies any
+// overrides present on the current buildapiv1.Build before running the
+// strategy. This mirrors the upstream `s2i rebuild` flow and lets a Build
+// be triggered against an image whose BuildConfig fields are absent or
+// stale (for example, a CVE-driven rebase where only the builder image
+// changed).
+func (s *S2IBuilder) Rebuild(image string) error {
+	ctx := timing.NewContext(context.Background())
+	defer func() {
+		s.build.Status.Stages = timing.AppendStageAndStepInfo(s.build.Status.Stages, timing.GetStages(ctx))
+		HandleBuildStatusUpdate(s.build, s.client, nil)
+	}()
+
+	labels, err := getImageLabels(s.backend, image)
+	if err != nil {
+		return fmt.Errorf("error inspecting %s for rebuild: %v", image, err)
+	}
+
+	config, err := configFromRebuildLabels(image, labels)
+	if err != nil {
+		return fmt.Errorf("error reconstructing S2I config from %s: %v", image, err)
+	}
+	config.DockerConfig = &s2iapi.DockerConfig{Endpoint: s.dockerSocket}
+	config.LabelNamespace = builderutil.DefaultDockerLabelNamespace
+	if strategy := s.build.Spec.Strategy.SourceStrategy; strategy != nil {
+		config.BlockOnBuild = strategy.Strategy == buildapiv1.SourceBuildStrategyTypeS2I
+	}
+
+	// Overrides from the current Build, if any were supplied, take
+	// precedence over what was recovered from the image labels.
+	if strategy := s.build.Spec.Strategy.SourceStrategy; strategy != nil {
+		if strategy.From.Name != "" {
+			config.BuilderImage = strategy.From.Name
+		}
+		if strategy.Scripts != "" {
+			config.ScriptsURL = strategy.Scripts
+		}
+		if strategy.Incremental != nil {
+			config.Incremental = *strategy.Incremental
+		}
+		if len(s.build.Spec.Source.ContextDir) != 0 {
+			config.ContextDir = filepath.Clean(s.build.Spec.Source.ContextDir)
+		}
+	}
+
+	if errs := s.validator.ValidateConfig(config); len(errs) != 0 {
+		var buffer bytes.Buffer
+		for _, ve := range errs {
+			buffer.WriteString(ve.Error())
+			buffer.WriteString(", ")
+		}
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointValidateConfig, buffer.String())
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		return errors.New(buffer.String())
+	}
+
+	if err := imagesign.VerifyImage(ctx, signingSystemContext(config.BuilderImage), s.signing, config.BuilderImage); err != nil {
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointVerifyImage, err.Error())
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		return err
+	}
+
+	// The whole point of a rebuild is to pick up a changed builder image
+	// (e.g. a CVE rebase), so always pull it rather than reusing whatever
+	// happens to be cached locally.
+	searchPaths := dockercfg.NewHelper().GetDockerAuthSearchPaths(dockercfg.PullAuthType)
+	if err := s.pullImage(config.BuilderImage, searchPaths); err != nil {
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointPullBuilderImage, err.Error())
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		return err
+	}
+
+	builder, buildInfo, err := s.builder.Builder(config, s2ibuild.Overrides{Downloader: nil})
+	if err != nil {
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointAssemble, string(buildInfo.FailureReason.Message))
+		HandleBuildStatusUpdate(s.build, s.client, nil)
+		return err
+	}
+
+	log.V(0).Infof("Rebuilding from image %s using recovered S2I config", image)
+	result, err := builder.Build(config)
+
+	for _, stage := range result.BuildInfo.Stages {
+		for _, step := range stage.Steps {
+			timing.RecordNewStep(ctx, buildapiv1.StageName(stage.Name), buildapiv1.StepName(step.Name), metav1.NewTime(step.StartTime), metav1.NewTime(step.StartTime.Add(time.Duration(step.DurationMilliseconds)*time.Millisecond)))
+		}
+	}
+
+	if err != nil {
+		s.build.Status.Phase = buildapiv1.BuildPhaseFailed
+		detail := ""
+		if result != nil {
+			detail = string(result.BuildInfo.FailureReason.Message)
+		}
+		builderutil.SetFailureReason(s.build, builderutil.FailurePointAssemble, detail)
+		HandleBuildStatusUpdate(s.build, s.client, nil)
+		return err
+	}
+
+	var push bool
+	if s.build.Spec.Output.To == nil || len(s.build.Spec.Output.To.Name) == 0 {
+		s.build.Status.OutputDockerImageReference = s.build.Name
+	} else {
+		push = true
+	}
+	pushTag := s.build.Status.OutputDockerImageReference
+	buildTag := randomBuildTag(s.build.Namespace, s.build.Name)
+
+	return s.commitAndPublish(ctx, config, buildTag, pushTag, push)
+}
+
+// configFromRebuildLabels reconstructs the subset of s2iapi.Config needed
+// to re-run a build from the io.openshift.s2i.* labels recorded on a
+// previously-built image. image is the image being rebuilt from (the
+// previous build's output), used as the incremental-build source since
+// that's what holds the prior build's artifacts - not the builder image
+// recovered from the labels.
+func configFromRebuildLabels(image string, labels map[string]string) (*s2iapi.Config, error) {
+	builderImage := labels[rebuildLabelPrefix+"build.image"]
+	if len(builderImage) == 0 {
+		return nil, fmt.Errorf("image is missing the %q label; it was not produced by an S2I build", rebuildLabelPrefix+"build.image")
+	}
+
+	config := &s2iapi.Config{
+		PreserveWorkingDir: true,
+		WorkingDir:         "/tmp",
+		BuilderImage:       builderImage,
+		ScriptsURL:         labels[rebuildLabelPrefix+"scripts-url"],
+		ForceCopy:          true,
+		AsDockerfile:       "/tmp/dockercontext/Dockerfile",
+		KeepSymlinks:       true,
+	}
+
+	if sourceURL := labels[rebuildLabelPrefix+"build.source-location"]; len(sourceURL) != 0 {
+		config.Source = &s2igit.URL{URL: url.URL{Path: sourceURL}, Type: s2igit.URLTypeLocal}
+	} else {
+		config.Source = &s2igit.URL{URL: url.URL{Path: InputContentPath}, Type: s2igit.URLTypeLocal}
+	}
+
+	if contextDir := labels[rebuildLabelPrefix+"build.source-context-dir"]; len(contextDir) != 0 {
+		config.ContextDir = filepath.Clean(contextDir)
+	}
+
+	if incremental := labels[rebuildLabelPrefix+"build.incremental"]; incremental == "true" {
+		// Incremental builds pull prior build artifacts from the
+		// previous *output* image, not from the builder image.
+		config.Incremental = true
+		config.IncrementalFromTag = image
+	}
+
+	return config, nil
+}
+
 // setupPullSecret provides a Docker authentication configuration when the
 // PullSecret is specified.
 func (s *S2IBuilder) setupPullSecret() *dockerclient.AuthConfigurations {
@@ -525,19 +798,15 @@ func (s *S2IBuilder) setupPullSecret() *dockerclient.AuthConfigurations {
 }
 
 func (s *S2IBuilder) pullImage(name string, searchPaths []string) error {
-	log.V(2).Infof("Explicitly pulling image %s", name)
-	repository, tag := dockerclient.ParseRepositoryTag(name)
-	options := dockerclient.PullImageOptions{
-		Repository: repository,
-		Tag:        tag,
-	}
+	log.V(2).Infof("Explicitly pulling image %s", canonicalImageName(name))
 
-	if options.Tag == "" && strings.Contains(name, "@") {
-		options.Repository = name
+	var progress io.Writer
+	if s.progress != nil {
+		progress = newProgressWriter(s.progress, buildapiv1.StagePullImages, buildapiv1.StepPullBaseImage)
 	}
 
 	return retryImageAction("Pull", func() (pullErr error) {
-		return s.dockerClient.PullImage(options, searchPaths)
+		return s.backend.PullImage(name, searchPaths, progress)
 	})
 }
 
@@ -551,6 +820,9 @@ func (s *S2IBuilder) pushImage(name string, authConfig dockerclient.AuthConfigur
 		Name: repository,
 		Tag:  tag,
 	}
+	if s.progress != nil {
+		options.OutputStream = newProgressWriter(s.progress, buildapiv1.StagePushImage, buildapiv1.StepPushImage)
+	}
 	var err error
 	sha := ""
 	retryImageAction("Push", func() (pushErr error) {
@@ -560,6 +832,58 @@ func (s *S2IBuilder) pushImage(name string, authConfig dockerclient.AuthConfigur
 	return sha, err
 }
 
+// progressWriter implements io.Writer and turns raw bytes written by the
+// Docker client (JSON progress frames for a pull/push) into Progress
+// updates, tracking how many bytes have been read so far for the given
+// stage/step. It does not attempt to parse the individual JSON frames;
+// the byte count is a reasonable proxy for "work done" and is cheap to
+// compute on every write.
+type progressWriter struct {
+	reporter timing.ProgressReporter
+	stage    buildapiv1.StageName
+	step     buildapiv1.StepName
+	read     int64
+}
+
+func newProgressWriter(reporter timing.ProgressReporter, stage buildapiv1.StageName, step buildapiv1.StepName) *progressWriter {
+	return &progressWriter{reporter: reporter, stage: stage, step: step}
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.read += int64(len(p))
+	w.reporter.Report(timing.Progress{Stage: string(w.stage), Step: string(w.step), Current: w.read})
+	return len(p), nil
+}
+
+// dockerfileStepWriter scans the Docker build log for "Step N/M" lines
+// and reports the current instruction index/total as build progress.
+type dockerfileStepWriter struct {
+	reporter timing.ProgressReporter
+	buf      bytes.Buffer
+}
+
+func newDockerfileStepWriter(reporter timing.ProgressReporter) *dockerfileStepWriter {
+	return &dockerfileStepWriter{reporter: reporter}
+}
+
+func (w *dockerfileStepWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Put back the partial line for the next Write.
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+		var current, total int64
+		if n, scanErr := fmt.Sscanf(line, "Step %d/%d", &current, &total); scanErr == nil && n == 2 {
+			w.reporter.Report(timing.Progress{Stage: string(buildapiv1.StageBuild), Step: string(buildapiv1.StepDockerBuild), Current: current, Total: total})
+		}
+	}
+	return len(p), nil
+}
+
 // buildEnvVars returns a map with build metadata to be inserted into Docker
 // images produced by build. It transforms the output from buildInfo into the
 // input format expected by s2iapi.Config.Environment.
@@ -652,26 +976,87 @@ func copyToVolumeList(artifactsMapping []buildapiv1.ImageSourcePath) (volumeList
 	return
 }
 
-func convertS2IFailureType(reason s2iapi.StepFailureReason, message s2iapi.StepFailureMessage) (buildapiv1.StatusReason, string) {
-	return buildapiv1.StatusReason(reason), string(message)
+// canonicalImageName logs and returns the canonical (fully qualified)
+// form of imageTag so inspect/pull calls are reproducible regardless of
+// how the image was originally named (unqualified, tag- or
+// digest-pinned, with or without a registry port). Invalid references
+// are passed through unchanged; InspectImage/PullImage are left to
+// report the actual parse/lookup error.
+func canonicalImageName(imageTag string) string {
+	ref, err := imageref.ParseImageRef(imageTag)
+	if err != nil {
+		return imageTag
+	}
+	log.V(5).Infof("Resolved image reference %q to canonical form %s", imageTag, ref)
+	logRegistryPolicy(ref.Domain)
+	return ref.String()
+}
+
+// logRegistryPolicy surfaces the effective TLS-verify/blocked/mirror
+// decision registries.conf produces for domain, so it's visible in
+// --loglevel=5 output why a pull did or didn't verify certificates or
+// was rewritten to a mirror.
+func logRegistryPolicy(domain string) {
+	decision, err := registrypolicy.NewResolver(nil, nil).Resolve(domain)
+	if err != nil {
+		log.V(5).Infof("Could not evaluate registries.conf policy for %s: %v", domain, err)
+		return
+	}
+	log.V(5).Infof("Registry policy for %s: tlsVerify=%t blocked=%t location=%s", domain, decision.TLSVerify, decision.Blocked, decision.Location)
+}
+
+// signingSystemContext builds the types.SystemContext imagesign.VerifyImage
+// and imagesign.SignAndStore need to reach ref's registry: credentials
+// from the same Docker/Podman auth-file discovery registryauth uses
+// elsewhere, and the TLS-verify decision registrypolicy computes from
+// registries.conf. Without this, verify/sign always dialed a registry
+// marked insecure in registries.conf with full certificate verification,
+// even though the matching pull through storageBackend honored that
+// policy. The --authfile and --tls-verify overrides passed to
+// SetImageBackend are not applied here: credentials come from the
+// default discovery paths and TLS verification from registries.conf
+// alone.
+func signingSystemContext(ref string) *types.SystemContext {
+	sys := &types.SystemContext{}
+	parsed, err := imageref.ParseImageRef(ref)
+	if err != nil {
+		return sys
+	}
+
+	if auth, err := registryauth.NewResolver(registryauth.DiscoveryPaths("")); err == nil {
+		authConfig := auth.Resolve(parsed.Domain)
+		if len(authConfig.Username) != 0 || len(authConfig.Password) != 0 {
+			sys.DockerAuthConfig = &types.DockerAuthConfig{
+				Username: authConfig.Username,
+				Password: authConfig.Password,
+			}
+		}
+	}
+
+	decision, err := registrypolicy.NewResolver(nil, nil).Resolve(parsed.Domain)
+	if err == nil && !decision.TLSVerify {
+		sys.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+	}
+	return sys
 }
 
-func isImagePresent(docker DockerClient, imageTag string) bool {
+func isImagePresent(backend imagebackend.Backend, imageTag string) bool {
 	// TODO: buildah may let us check if image is present without grabbing full JSON
-	image, err := docker.InspectImage(imageTag)
+	image, err := backend.InspectImage(imageTag)
 	return err == nil && image != nil
 }
 
-func getImageLabels(docker DockerClient, imageTag string) (map[string]string, error) {
-	image, err := docker.InspectImage(imageTag)
+func getImageLabels(backend imagebackend.Backend, imageTag string) (map[string]string, error) {
+	canonicalImageName(imageTag)
+	image, err := backend.InspectImage(imageTag)
 	if err != nil {
 		return nil, err
 	}
 	return image.ContainerConfig.Labels, nil
 }
 
-func getAssembleUser(docker DockerClient, imageTag string) (string, error) {
-	image, err := docker.InspectImage(imageTag)
+func getAssembleUser(backend imagebackend.Backend, imageTag string) (string, error) {
+	image, err := backend.InspectImage(imageTag)
 	if err != nil {
 		return "", err
 	}
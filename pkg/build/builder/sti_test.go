@@ -0,0 +1,468 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	dockerclient "github.com/fsouza/go-dockerclient"
+
+	buildapiv1 "github.com/openshift/api/build/v1"
+	s2iapi "github.com/openshift/source-to-image/pkg/api"
+	"github.com/openshift/source-to-image/pkg/api/validation"
+	s2ibuild "github.com/openshift/source-to-image/pkg/build"
+
+	"github.com/openshift/builder/pkg/build/builder/confidential"
+	"github.com/openshift/builder/pkg/build/builder/imagebackend"
+	"github.com/openshift/builder/pkg/build/builder/imagesign"
+	builderutil "github.com/openshift/builder/pkg/build/builder/util"
+)
+
+// fakeDockerClient implements the subset of DockerClient that
+// commitAndPublish (and the post-commit hook it runs) call, letting each
+// test force a failure at a specific point without a real Docker daemon.
+type fakeDockerClient struct {
+	buildImage   func(opts dockerclient.BuildImageOptions) error
+	tagImage     func(name string, opts dockerclient.TagImageOptions) error
+	pushImage    func(opts dockerclient.PushImageOptions, auth dockerclient.AuthConfiguration) (string, error)
+	inspectImage func(name string) (*dockerclient.Image, error)
+
+	createContainer   func(opts dockerclient.CreateContainerOptions) (*dockerclient.Container, error)
+	startContainer    func(id string, hostConfig *dockerclient.HostConfig) error
+	attachToContainer func(opts dockerclient.AttachToContainerOptions) (dockerclient.CloseWaiter, error)
+	waitContainer     func(id string) (int, error)
+	removeContainer   func(opts dockerclient.RemoveContainerOptions) error
+}
+
+func (f *fakeDockerClient) BuildImage(opts dockerclient.BuildImageOptions) error {
+	if f.buildImage != nil {
+		return f.buildImage(opts)
+	}
+	return nil
+}
+
+func (f *fakeDockerClient) TagImage(name string, opts dockerclient.TagImageOptions) error {
+	if f.tagImage != nil {
+		return f.tagImage(name, opts)
+	}
+	return nil
+}
+
+func (f *fakeDockerClient) PushImage(opts dockerclient.PushImageOptions, auth dockerclient.AuthConfiguration) (string, error) {
+	if f.pushImage != nil {
+		return f.pushImage(opts, auth)
+	}
+	return "sha256:deadbeef", nil
+}
+
+func (f *fakeDockerClient) PullImage(opts dockerclient.PullImageOptions, searchPaths []string) error {
+	return nil
+}
+
+func (f *fakeDockerClient) InspectImage(name string) (*dockerclient.Image, error) {
+	if f.inspectImage != nil {
+		return f.inspectImage(name)
+	}
+	return &dockerclient.Image{}, nil
+}
+
+func (f *fakeDockerClient) CreateContainer(opts dockerclient.CreateContainerOptions) (*dockerclient.Container, error) {
+	if f.createContainer != nil {
+		return f.createContainer(opts)
+	}
+	return &dockerclient.Container{ID: "fake-container"}, nil
+}
+
+func (f *fakeDockerClient) StartContainer(id string, hostConfig *dockerclient.HostConfig) error {
+	if f.startContainer != nil {
+		return f.startContainer(id, hostConfig)
+	}
+	return nil
+}
+
+func (f *fakeDockerClient) AttachToContainer(opts dockerclient.AttachToContainerOptions) (dockerclient.CloseWaiter, error) {
+	if f.attachToContainer != nil {
+		return f.attachToContainer(opts)
+	}
+	return nil, nil
+}
+
+func (f *fakeDockerClient) WaitContainer(id string) (int, error) {
+	if f.waitContainer != nil {
+		return f.waitContainer(id)
+	}
+	return 0, nil
+}
+
+func (f *fakeDockerClient) RemoveContainer(opts dockerclient.RemoveContainerOptions) error {
+	if f.removeContainer != nil {
+		return f.removeContainer(opts)
+	}
+	return nil
+}
+
+func newTestBuild() *buildapiv1.Build {
+	build := &buildapiv1.Build{}
+	build.Name = "test-build"
+	build.Namespace = "test-namespace"
+	return build
+}
+
+// newTestS2IBuilder builds an S2IBuilder for exercising commitAndPublish
+// directly; builder/validator are left nil since commitAndPublish runs
+// after both have already done their work.
+func newTestS2IBuilder(docker DockerClient, build *buildapiv1.Build) *S2IBuilder {
+	return newS2IBuilder(docker, "", nil, build, nil, nil, nil)
+}
+
+// fakeBuilderFactory lets a test force Builder's error/BuildInfo without
+// exercising the real S2I/onbuild strategy selection.
+type fakeBuilderFactory struct {
+	builder func(config *s2iapi.Config, overrides s2ibuild.Overrides) (s2ibuild.Builder, s2iapi.BuildInfo, error)
+}
+
+func (f *fakeBuilderFactory) Builder(config *s2iapi.Config, overrides s2ibuild.Overrides) (s2ibuild.Builder, s2iapi.BuildInfo, error) {
+	return f.builder(config, overrides)
+}
+
+// fakeS2IBuilder lets a test force the outcome of the S2I execution step
+// (s2ibuild.Builder.Build), as opposed to fakeBuilderFactory, which only
+// forces the outcome of constructing that Builder.
+type fakeS2IBuilder struct {
+	build func(config *s2iapi.Config) (*s2iapi.Result, error)
+}
+
+func (f *fakeS2IBuilder) Build(config *s2iapi.Config) (*s2iapi.Result, error) {
+	return f.build(config)
+}
+
+// fakeValidator lets a test force ValidateConfig's returned errors.
+type fakeValidator struct {
+	errs func(config *s2iapi.Config) []validation.Error
+}
+
+func (f *fakeValidator) ValidateConfig(config *s2iapi.Config) []validation.Error {
+	if f.errs != nil {
+		return f.errs(config)
+	}
+	return nil
+}
+
+// fakeImageBackend implements imagebackend.Backend for tests that drive
+// Rebuild, which looks up the prior build's image labels and pulls the
+// recovered builder image through s.backend rather than s.dockerClient.
+type fakeImageBackend struct {
+	inspectImage func(ref string) (*imagebackend.Image, error)
+	pullImage    func(ref string, searchPaths []string, progress io.Writer) error
+}
+
+func (f *fakeImageBackend) InspectImage(ref string) (*imagebackend.Image, error) {
+	if f.inspectImage != nil {
+		return f.inspectImage(ref)
+	}
+	return &imagebackend.Image{}, nil
+}
+
+func (f *fakeImageBackend) PullImage(ref string, searchPaths []string, progress io.Writer) error {
+	if f.pullImage != nil {
+		return f.pullImage(ref, searchPaths, progress)
+	}
+	return nil
+}
+
+func (f *fakeImageBackend) TagImage(src, dst string) error {
+	return nil
+}
+
+// rebuildLabels returns the io.openshift.s2i.* labels Rebuild needs to
+// recover an S2I config, as if read off a previously-built image.
+func rebuildLabels() map[string]string {
+	return map[string]string{rebuildLabelPrefix + "build.image": "builder-image:latest"}
+}
+
+// newTestRebuildS2IBuilder builds an S2IBuilder for exercising Rebuild,
+// with its backend stubbed to return rebuildLabels() so getImageLabels
+// succeeds regardless of the failure point under test.
+func newTestRebuildS2IBuilder(build *buildapiv1.Build, builder builderFactory, v validator, backend imagebackend.Backend) *S2IBuilder {
+	s := newS2IBuilder(&fakeDockerClient{}, "", nil, build, builder, v, nil)
+	s.backend = backend
+	return s
+}
+
+// TestRebuildFailurePaths forces a failure at each stage of Rebuild that
+// precedes commitAndPublish (already covered by
+// TestCommitAndPublishFailurePaths) and asserts that
+// build.Status.Reason/Message come from the matching FailurePoint. The
+// pull-incremental-image and attestation-register points aren't
+// exercised here: Rebuild doesn't do a separate incremental-image pull
+// the way Build does, and reaching a real Register call requires a
+// confidential backend whose Generate step shells out to tooling (e.g.
+// cw-build) that isn't available in this package snapshot.
+func TestRebuildFailurePaths(t *testing.T) {
+	okBuilder := &fakeBuilderFactory{
+		builder: func(config *s2iapi.Config, overrides s2ibuild.Overrides) (s2ibuild.Builder, s2iapi.BuildInfo, error) {
+			t.Fatal("builder.Builder should not be reached")
+			return nil, s2iapi.BuildInfo{}, nil
+		},
+	}
+	okValidator := &fakeValidator{}
+	okBackend := &fakeImageBackend{
+		inspectImage: func(ref string) (*imagebackend.Image, error) {
+			return &imagebackend.Image{ContainerConfig: dockerclient.Config{Labels: rebuildLabels()}}, nil
+		},
+	}
+
+	tests := []struct {
+		name      string
+		builder   builderFactory
+		validator validator
+		backend   imagebackend.Backend
+		signing   imagesign.Config
+		wantPoint builderutil.FailurePoint
+	}{
+		{
+			name:    "validate config fails",
+			builder: okBuilder,
+			validator: &fakeValidator{errs: func(config *s2iapi.Config) []validation.Error {
+				return []validation.Error{{Message: "bad config"}}
+			}},
+			backend:   okBackend,
+			wantPoint: builderutil.FailurePointValidateConfig,
+		},
+		{
+			name:      "verify image fails",
+			builder:   okBuilder,
+			validator: okValidator,
+			backend:   okBackend,
+			signing:   imagesign.Config{SignaturePolicyPath: "/nonexistent/policy.json"},
+			wantPoint: builderutil.FailurePointVerifyImage,
+		},
+		{
+			name:      "pull builder image fails",
+			builder:   okBuilder,
+			validator: okValidator,
+			backend: &fakeImageBackend{
+				inspectImage: okBackend.inspectImage,
+				pullImage: func(ref string, searchPaths []string, progress io.Writer) error {
+					return errors.New("registry unreachable")
+				},
+			},
+			wantPoint: builderutil.FailurePointPullBuilderImage,
+		},
+		{
+			name: "assemble fails",
+			builder: &fakeBuilderFactory{
+				builder: func(config *s2iapi.Config, overrides s2ibuild.Overrides) (s2ibuild.Builder, s2iapi.BuildInfo, error) {
+					buildInfo := s2iapi.BuildInfo{}
+					buildInfo.FailureReason.Message = "assemble exploded"
+					return nil, buildInfo, errors.New("assemble exploded")
+				},
+			},
+			validator: okValidator,
+			backend:   okBackend,
+			wantPoint: builderutil.FailurePointAssemble,
+		},
+		{
+			name: "s2i build fails",
+			builder: &fakeBuilderFactory{
+				builder: func(config *s2iapi.Config, overrides s2ibuild.Overrides) (s2ibuild.Builder, s2iapi.BuildInfo, error) {
+					return &fakeS2IBuilder{
+						build: func(config *s2iapi.Config) (*s2iapi.Result, error) {
+							result := &s2iapi.Result{}
+							result.BuildInfo.FailureReason.Message = "assemble exploded"
+							return result, errors.New("assemble exploded")
+						},
+					}, s2iapi.BuildInfo{}, nil
+				},
+			},
+			validator: okValidator,
+			backend:   okBackend,
+			wantPoint: builderutil.FailurePointAssemble,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := newTestBuild()
+			s := newTestRebuildS2IBuilder(build, tt.builder, tt.validator, tt.backend)
+			s.signing = tt.signing
+
+			err := s.Rebuild("prior-output-image:latest")
+			if err == nil {
+				t.Fatalf("expected an error, got nil")
+			}
+
+			wantReason, wantMessage := builderutil.NewFailureReason(tt.wantPoint)
+			if build.Status.Reason != wantReason {
+				t.Errorf("Status.Reason = %s, want %s", build.Status.Reason, wantReason)
+			}
+			if !strings.HasPrefix(build.Status.Message, wantMessage) {
+				t.Errorf("Status.Message = %q, want prefix %q", build.Status.Message, wantMessage)
+			}
+			if build.Status.Phase != buildapiv1.BuildPhaseFailed {
+				t.Errorf("Status.Phase = %s, want %s", build.Status.Phase, buildapiv1.BuildPhaseFailed)
+			}
+		})
+	}
+}
+
+// TestRebuildBlockOnBuild asserts that Rebuild only forces
+// config.BlockOnBuild when the Build explicitly requests the S2I
+// strategy; Auto (or no override at all) must leave it unset so
+// runtimeBuilderFactory.Builder's own ONBUILD auto-detection can still
+// pick the onbuild strategy.
+func TestRebuildBlockOnBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy buildapiv1.SourceBuildStrategyType
+		want     bool
+	}{
+		{name: "no override", want: false},
+		{name: "auto strategy", strategy: buildapiv1.SourceBuildStrategyTypeAuto, want: false},
+		{name: "explicit S2I strategy", strategy: buildapiv1.SourceBuildStrategyTypeS2I, want: true},
+	}
+
+	backend := &fakeImageBackend{
+		inspectImage: func(ref string) (*imagebackend.Image, error) {
+			return &imagebackend.Image{ContainerConfig: dockerclient.Config{Labels: rebuildLabels()}}, nil
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBlockOnBuild bool
+			builder := &fakeBuilderFactory{
+				builder: func(config *s2iapi.Config, overrides s2ibuild.Overrides) (s2ibuild.Builder, s2iapi.BuildInfo, error) {
+					gotBlockOnBuild = config.BlockOnBuild
+					return &fakeS2IBuilder{
+						build: func(config *s2iapi.Config) (*s2iapi.Result, error) {
+							return &s2iapi.Result{}, errors.New("stop before commitAndPublish")
+						},
+					}, s2iapi.BuildInfo{}, nil
+				},
+			}
+
+			build := newTestBuild()
+			if len(tt.strategy) != 0 {
+				build.Spec.Strategy.SourceStrategy = &buildapiv1.SourceBuildStrategy{Strategy: tt.strategy}
+			}
+			s := newTestRebuildS2IBuilder(build, builder, &fakeValidator{}, backend)
+
+			if err := s.Rebuild("prior-output-image:latest"); err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+			if gotBlockOnBuild != tt.want {
+				t.Errorf("config.BlockOnBuild = %v, want %v", gotBlockOnBuild, tt.want)
+			}
+		})
+	}
+}
+
+// TestCommitAndPublishFailurePaths forces a failure at each stage of
+// commitAndPublish (the tail shared by Build and Rebuild) and asserts
+// that build.Status.Reason/Message come from the matching FailurePoint
+// rather than the generic fallback. The stages that precede
+// commitAndPublish - pull, config validation, signature verification,
+// S2I assemble - are covered via Rebuild in TestRebuildFailurePaths
+// instead, since Build additionally depends on readSourceInfo, which
+// lives outside this package snapshot.
+func TestCommitAndPublishFailurePaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		docker    *fakeDockerClient
+		build     func(*buildapiv1.Build)
+		signing   imagesign.Config
+		push      bool
+		wantPoint builderutil.FailurePoint
+	}{
+		{
+			name: "docker build fails",
+			docker: &fakeDockerClient{
+				buildImage: func(opts dockerclient.BuildImageOptions) error {
+					return errors.New("build exploded")
+				},
+			},
+			push:      true,
+			wantPoint: builderutil.FailurePointDockerBuild,
+		},
+		{
+			name: "post-commit hook container fails to create",
+			docker: &fakeDockerClient{
+				createContainer: func(opts dockerclient.CreateContainerOptions) (*dockerclient.Container, error) {
+					return nil, errors.New("daemon unreachable")
+				},
+			},
+			build: func(b *buildapiv1.Build) {
+				b.Spec.PostCommit.Script = "exit 1"
+			},
+			push:      true,
+			wantPoint: builderutil.FailurePointPostCommit,
+		},
+		{
+			name:   "unknown confidential backend",
+			docker: &fakeDockerClient{},
+			build: func(b *buildapiv1.Build) {
+				b.Annotations = map[string]string{confidential.AnnotationConfidentialImage: "not-a-real-backend"}
+			},
+			push:      true,
+			wantPoint: builderutil.FailurePointConfidentialImage,
+		},
+		{
+			name: "tag fails",
+			docker: &fakeDockerClient{
+				tagImage: func(name string, opts dockerclient.TagImageOptions) error {
+					return errors.New("tag rejected")
+				},
+			},
+			push:      true,
+			wantPoint: builderutil.FailurePointTagImage,
+		},
+		{
+			name: "push fails",
+			docker: &fakeDockerClient{
+				pushImage: func(opts dockerclient.PushImageOptions, auth dockerclient.AuthConfiguration) (string, error) {
+					return "", errors.New("registry unavailable")
+				},
+			},
+			push:      true,
+			wantPoint: builderutil.FailurePointPushImage,
+		},
+		{
+			name:      "sign fails when no sigstore is configured",
+			docker:    &fakeDockerClient{},
+			signing:   imagesign.Config{SignBy: "test-key"},
+			push:      true,
+			wantPoint: builderutil.FailurePointSignImage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := newTestBuild()
+			if tt.build != nil {
+				tt.build(build)
+			}
+			s := newTestS2IBuilder(tt.docker, build)
+			s.signing = tt.signing
+
+			config := &s2iapi.Config{AsDockerfile: "/nonexistent/Dockerfile"}
+			err := s.commitAndPublish(context.Background(), config, "build-tag:latest", "push-tag:latest", tt.push)
+			if err == nil {
+				t.Fatalf("expected an error, got nil")
+			}
+
+			wantReason, wantMessage := builderutil.NewFailureReason(tt.wantPoint)
+			if build.Status.Reason != wantReason {
+				t.Errorf("Status.Reason = %s, want %s", build.Status.Reason, wantReason)
+			}
+			if !strings.HasPrefix(build.Status.Message, wantMessage) {
+				t.Errorf("Status.Message = %q, want prefix %q", build.Status.Message, wantMessage)
+			}
+			if build.Status.Phase != buildapiv1.BuildPhaseFailed {
+				t.Errorf("Status.Phase = %s, want %s", build.Status.Phase, buildapiv1.BuildPhaseFailed)
+			}
+		})
+	}
+}
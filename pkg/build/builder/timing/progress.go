@@ -0,0 +1,95 @@
+package timing
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress describes the current point reached in a build, suitable for
+// reporting back to the Build status subresource so `oc get build -w`
+// and controllers can show live progress instead of only stage
+// transitions.
+type Progress struct {
+	// Stage and Step mirror the names used by RecordNewStep, e.g.
+	// StagePullImages / StepPullBaseImage.
+	Stage string
+	Step  string
+	// Current and Total describe progress within Step, e.g. bytes
+	// pulled/pushed so far and the total reported by the registry, or
+	// the current and final Dockerfile instruction index. Total may be
+	// zero when it isn't known in advance.
+	Current int64
+	Total   int64
+}
+
+func (p Progress) String() string {
+	if p.Total > 0 {
+		return fmt.Sprintf("%s/%s: %d/%d", p.Stage, p.Step, p.Current, p.Total)
+	}
+	return fmt.Sprintf("%s/%s: %d", p.Stage, p.Step, p.Current)
+}
+
+// ProgressReporter receives Progress updates from whichever stage of the
+// build is currently running. Implementations decide how (and how often)
+// to surface them; DockerBuilder and S2IBuilder share the same
+// implementation so progress looks identical across strategies.
+type ProgressReporter interface {
+	// Report records the latest Progress for the currently running
+	// step. It may be called far more often than the reporter actually
+	// flushes.
+	Report(p Progress)
+	// Flush forces delivery of the most recently reported Progress if it
+	// hasn't already been flushed. Callers should invoke this once the
+	// work being reported on finishes, so the reporter's throttling
+	// interval can't leave a stale, non-final Progress in place.
+	Flush()
+}
+
+// FlushFunc persists the most recent Progress, e.g. by writing it to
+// build.Status.BuildProgress and calling HandleBuildStatusUpdate.
+type FlushFunc func(p Progress)
+
+// NewThrottledReporter returns a ProgressReporter that calls flush at
+// most once per interval, always with the most recent Progress reported
+// since the last flush. This keeps `oc get build -w` responsive without
+// hammering the Build status subresource on every byte read.
+func NewThrottledReporter(interval time.Duration, flush FlushFunc) ProgressReporter {
+	return &throttledReporter{interval: interval, flush: flush}
+}
+
+type throttledReporter struct {
+	interval time.Duration
+	flush    FlushFunc
+
+	mu       sync.Mutex
+	last     time.Time
+	pending  Progress
+	hasFirst bool
+	dirty    bool
+}
+
+func (r *throttledReporter) Report(p Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = p
+	r.dirty = true
+	now := time.Now()
+	if !r.hasFirst || now.Sub(r.last) >= r.interval {
+		r.hasFirst = true
+		r.last = now
+		r.dirty = false
+		r.flush(p)
+	}
+}
+
+func (r *throttledReporter) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return
+	}
+	r.dirty = false
+	r.last = time.Now()
+	r.flush(r.pending)
+}
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"fmt"
+
+	buildapiv1 "github.com/openshift/api/build/v1"
+)
+
+// FailurePoint identifies a specific place in the build pipeline
+// (S2IBuilder.Build and DockerBuilder.Build) that can fail, so a more
+// specific buildapiv1.StatusReason and message can be attached to it
+// instead of the catch-all StatusReasonGenericBuildFailed. buildapiv1
+// doesn't define a StatusReason for every point in the table below;
+// those still report StatusReasonGenericBuildFailed, but with a message
+// naming the step that failed rather than no detail at all.
+type FailurePoint string
+
+const (
+	FailurePointPullBuilderImage     FailurePoint = "PullBuilderImage"
+	FailurePointPullIncrementalImage FailurePoint = "PullIncrementalImage"
+	FailurePointValidateConfig       FailurePoint = "ValidateConfig"
+	FailurePointAssemble             FailurePoint = "Assemble"
+	FailurePointDockerBuild          FailurePoint = "DockerBuild"
+	FailurePointPostCommit           FailurePoint = "PostCommit"
+	FailurePointTagImage             FailurePoint = "TagImage"
+	FailurePointPushImage            FailurePoint = "PushImage"
+	FailurePointConfidentialImage    FailurePoint = "ConfidentialImage"
+	FailurePointAttestationRegister  FailurePoint = "AttestationRegister"
+	FailurePointVerifyImage          FailurePoint = "VerifyImage"
+	FailurePointSignImage            FailurePoint = "SignImage"
+)
+
+// failureReason pairs the StatusReason controllers key off of with the
+// human-readable message shown in `oc describe build`.
+type failureReason struct {
+	Reason  buildapiv1.StatusReason
+	Message string
+}
+
+// failureReasons maps each FailurePoint to the StatusReason/Message
+// reported when it fails. S2IBuilder sets these through SetFailureReason
+// at the pull, validation, verification, assemble, docker build,
+// post-commit, confidential-image, tag, push, sign and attestation
+// failures. Build's other early returns (a missing source strategy,
+// reading source info or proxy settings, inspecting the builder image
+// for its assemble user, labels or ONBUILD triggers, parsing allowed
+// UIDs) return their error without setting a failure reason. Several
+// points below share StatusReasonGenericBuildFailed because buildapiv1
+// has no more specific reason for them, but each still gets its own
+// descriptive message.
+var failureReasons = map[FailurePoint]failureReason{
+	FailurePointPullBuilderImage:     {buildapiv1.StatusReasonPullBuilderImageFailed, "Failed to pull builder image."},
+	FailurePointPullIncrementalImage: {buildapiv1.StatusReasonPullBuilderImageFailed, "Failed to pull the incremental image."},
+	FailurePointValidateConfig:       {buildapiv1.StatusReasonGenericBuildFailed, "The S2I build configuration is invalid."},
+	FailurePointAssemble:             {buildapiv1.StatusReasonGenericBuildFailed, "The S2I assemble step failed."},
+	FailurePointDockerBuild:          {buildapiv1.StatusReasonDockerBuildFailed, StatusMessageGenericBuildFailed},
+	FailurePointPostCommit:           {buildapiv1.StatusReasonPostCommitHookFailed, "The post-commit hook failed."},
+	FailurePointTagImage:             {buildapiv1.StatusReasonGenericBuildFailed, "Failed to tag the built image."},
+	FailurePointPushImage:            {buildapiv1.StatusReasonPushImageToRegistryFailed, StatusMessagePushImageToRegistryFailed},
+	FailurePointConfidentialImage:    {buildapiv1.StatusReasonGenericBuildFailed, "Failed to generate the confidential image."},
+	FailurePointAttestationRegister:  {buildapiv1.StatusReasonGenericBuildFailed, "Failed to register the image with the attestation server."},
+	FailurePointVerifyImage:          {buildapiv1.StatusReasonGenericBuildFailed, "The builder image did not satisfy the configured signature policy."},
+	FailurePointSignImage:            {buildapiv1.StatusReasonGenericBuildFailed, "Failed to sign the built image."},
+}
+
+// NewFailureReason returns the StatusReason/Message pair registered for
+// point, falling back to StatusReasonGenericBuildFailed if point isn't
+// in the table.
+func NewFailureReason(point FailurePoint) (buildapiv1.StatusReason, string) {
+	if fr, ok := failureReasons[point]; ok {
+		return fr.Reason, fr.Message
+	}
+	return buildapiv1.StatusReasonGenericBuildFailed, StatusMessageGenericBuildFailed
+}
+
+// SetFailureReason sets build.Status.Reason/Message for point, appending
+// detail (typically the underlying error) to the registered message when
+// one is provided.
+func SetFailureReason(build *buildapiv1.Build, point FailurePoint, detail string) {
+	reason, message := NewFailureReason(point)
+	build.Status.Reason = reason
+	if len(detail) != 0 {
+		message = fmt.Sprintf("%s %s", message, detail)
+	}
+	build.Status.Message = message
+}
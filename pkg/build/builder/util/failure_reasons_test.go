@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+
+	buildapiv1 "github.com/openshift/api/build/v1"
+)
+
+func TestNewFailureReasonKnownPoints(t *testing.T) {
+	for point, want := range failureReasons {
+		reason, message := NewFailureReason(point)
+		if reason != want.Reason {
+			t.Errorf("NewFailureReason(%s) reason = %s, want %s", point, reason, want.Reason)
+		}
+		if message != want.Message {
+			t.Errorf("NewFailureReason(%s) message = %q, want %q", point, message, want.Message)
+		}
+	}
+}
+
+func TestNewFailureReasonUnknownPoint(t *testing.T) {
+	reason, message := NewFailureReason(FailurePoint("not-a-real-point"))
+	if reason != buildapiv1.StatusReasonGenericBuildFailed {
+		t.Errorf("NewFailureReason(unknown) reason = %s, want %s", reason, buildapiv1.StatusReasonGenericBuildFailed)
+	}
+	if message != StatusMessageGenericBuildFailed {
+		t.Errorf("NewFailureReason(unknown) message = %q, want %q", message, StatusMessageGenericBuildFailed)
+	}
+}
+
+func TestSetFailureReasonAppendsDetail(t *testing.T) {
+	build := &buildapiv1.Build{}
+	SetFailureReason(build, FailurePointPushImage, "connection refused")
+
+	wantReason, wantMessage := NewFailureReason(FailurePointPushImage)
+	if build.Status.Reason != wantReason {
+		t.Errorf("Status.Reason = %s, want %s", build.Status.Reason, wantReason)
+	}
+	wantMessage += " connection refused"
+	if build.Status.Message != wantMessage {
+		t.Errorf("Status.Message = %q, want %q", build.Status.Message, wantMessage)
+	}
+}
+
+func TestSetFailureReasonNoDetail(t *testing.T) {
+	build := &buildapiv1.Build{}
+	SetFailureReason(build, FailurePointTagImage, "")
+
+	wantReason, wantMessage := NewFailureReason(FailurePointTagImage)
+	if build.Status.Reason != wantReason {
+		t.Errorf("Status.Reason = %s, want %s", build.Status.Reason, wantReason)
+	}
+	if build.Status.Message != wantMessage {
+		t.Errorf("Status.Message = %q, want %q", build.Status.Message, wantMessage)
+	}
+}